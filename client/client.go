@@ -0,0 +1,221 @@
+// Package client is a Go client for the Greenlight API.
+//
+// A Client sends the bearer token obtained through Authenticate with every
+// request, retries requests rejected by the rate limiter after the delay
+// given in their Retry-After header, and reports errors as *APIError values,
+// or the more specific *ValidationError and *EditConflictError ones.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultRetryDelay = time.Second
+	maxRetryDelay     = time.Minute
+)
+
+// Client is a client of the Greenlight API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	maxRetries int
+
+	mu    sync.RWMutex
+	token string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to send requests, which is
+// http.DefaultClient by default.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken sets the authentication token sent with every request.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithMaxRetries sets how many times a request rejected with a 429 Too Many
+// Requests response is retried, 3 by default.
+func WithMaxRetries(n int) Option {
+	return func(c *Client) {
+		c.maxRetries = n
+	}
+}
+
+// New returns a client of the API served at baseURL, such as
+// "https://greenlight.example.com".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("client: base URL %q must be an http or https URL", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		maxRetries: defaultMaxRetries,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// Token returns the authentication token sent with requests, if any.
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.token
+}
+
+// SetToken sets the authentication token sent with requests, an empty one
+// makes them anonymous.
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.token = token
+}
+
+// request describes a call to the API.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   any
+}
+
+// do sends the request, retrying it while it is rate limited, and decodes
+// the JSON envelope of a successful response into dst, when not nil.
+func (c *Client) do(ctx context.Context, req request, dst any) error {
+	var body []byte
+
+	if req.body != nil {
+		var err error
+
+		body, err = json.Marshal(req.body)
+		if err != nil {
+			return err
+		}
+	}
+
+	u := *c.baseURL
+	u.Path += req.path
+	u.RawQuery = req.query.Encode()
+
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+
+		for key, values := range req.header {
+			httpReq.Header[key] = values
+		}
+
+		httpReq.Header.Set("Accept", "application/json, application/problem+json")
+
+		if body != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+
+		if token := c.Token(); token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		res, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode == http.StatusTooManyRequests && attempt < c.maxRetries {
+			delay := retryDelay(res.Header.Get("Retry-After"), attempt)
+
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+
+			timer := time.NewTimer(delay)
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+
+			continue
+		}
+
+		return decodeResponse(res, dst)
+	}
+}
+
+// retryDelay returns how long to wait before retrying a rate limited
+// request, from its Retry-After header, in seconds or as an HTTP date, or
+// doubling from a second with each attempt when it is missing.
+func retryDelay(retryAfter string, attempt int) time.Duration {
+	delay := defaultRetryDelay << attempt
+
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(retryAfter); err == nil {
+		delay = time.Until(date)
+	}
+
+	switch {
+	case delay < 0:
+		return 0
+	case delay > maxRetryDelay:
+		return maxRetryDelay
+	default:
+		return delay
+	}
+}
+
+func decodeResponse(res *http.Response, dst any) error {
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return newError(res)
+	}
+
+	if dst == nil || res.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, res.Body)
+		return nil
+	}
+
+	err := json.NewDecoder(res.Body).Decode(dst)
+	if err != nil {
+		return fmt.Errorf("client: decoding response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+//go:build unit
+// +build unit
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := New(server.URL)
+	require.NoError(t, err)
+
+	return c
+}
+
+func TestAuthenticate(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/tokens/authentication":
+			require.Empty(t, r.Header.Get("Authorization"))
+			fmt.Fprint(w, `{"authenticationToken":{"token":"ABCDEFGHIJKLMNOPQRSTUVWXYZ","expiry":"2026-10-20T10:00:00Z"}}`)
+		case "/v1/movies/1":
+			require.Equal(t, "Bearer ABCDEFGHIJKLMNOPQRSTUVWXYZ", r.Header.Get("Authorization"))
+			fmt.Fprint(w, `{"movie":{"id":1,"title":"Up","runtime":"96 mins","version":2}}`)
+		}
+	})
+
+	token, err := c.Authenticate(context.Background(), "alice@example.com", "pa55word")
+	require.NoError(t, err)
+	require.Equal(t, "ABCDEFGHIJKLMNOPQRSTUVWXYZ", c.Token())
+	require.Equal(t, time.Date(2026, 10, 20, 10, 0, 0, 0, time.UTC), token.Expiry)
+
+	movie, err := c.GetMovie(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, Runtime(96), movie.Runtime)
+	require.Equal(t, int32(2), movie.Version)
+}
+
+func TestRetryAfter(t *testing.T) {
+	attempts := 0
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+
+		var input MovieInput
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+		require.Equal(t, "Up", input.Title)
+
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"movie":{"id":1,"title":"Up"}}`)
+	})
+
+	movie, err := c.CreateMovie(context.Background(), MovieInput{Title: "Up", Runtime: 96})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), movie.ID)
+	require.Equal(t, 3, attempts)
+
+	WithMaxRetries(1)(c)
+	attempts = 0
+
+	_, err = c.CreateMovie(context.Background(), MovieInput{Title: "Up"})
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	require.Equal(t, 2, attempts)
+}
+
+func TestRetryDelay(t *testing.T) {
+	require.Equal(t, 5*time.Second, retryDelay("5", 0))
+	require.Equal(t, time.Second, retryDelay("", 0))
+	require.Equal(t, 4*time.Second, retryDelay("soon", 2))
+	require.Equal(t, maxRetryDelay, retryDelay("3600", 0))
+	require.Equal(t, time.Duration(0), retryDelay(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0))
+}
+
+func TestErrors(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"type":"urn:greenlight:problem:validation_failed","status":422,"code":"validation_failed","requestId":"abc","invalidParams":[{"name":"title","reason":"must be provided"}]}`)
+		case http.MethodPatch:
+			require.Equal(t, `W/"3"`, r.Header.Get("If-Match"))
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusPreconditionFailed)
+			fmt.Fprint(w, `{"status":412,"code":"precondition_failed","detail":"the resource has been modified since it was read"}`)
+		case http.MethodDelete:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"the requested resource could not be found"}`)
+		}
+	})
+
+	_, err := c.CreateMovie(context.Background(), MovieInput{})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Equal(t, map[string]string{"title": "must be provided"}, validationErr.Fields)
+	require.Equal(t, "abc", validationErr.RequestID)
+
+	title := "Up"
+	_, err = c.UpdateMovie(context.Background(), 1, MovieUpdate{Title: &title, IfVersion: 3})
+	var conflictErr *EditConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	require.Equal(t, "precondition_failed", conflictErr.Code)
+
+	err = c.DeleteMovie(context.Background(), 1)
+	require.True(t, IsNotFound(err))
+	require.False(t, IsNotFound(validationErr))
+	require.False(t, errors.As(err, &validationErr))
+}
+
+func TestMoviesIterator(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "drama,comedy", r.URL.Query().Get("genres"))
+		require.Equal(t, "2", r.URL.Query().Get("pageSize"))
+
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		require.NoError(t, err)
+
+		ids := [][]int{{1, 2}, {3, 4}, {5}}[page-1]
+
+		movies := make([]Movie, len(ids))
+		for i, id := range ids {
+			movies[i] = Movie{ID: int64(id)}
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"metadata": Metadata{CurrentPage: page, PageSize: 2, FirstPage: 1, LastPage: 3, TotalRecords: 5},
+			"movies":   movies,
+		})
+	})
+
+	it := c.Movies(context.Background(), MovieFilter{Genres: []string{"drama", "comedy"}, PageSize: 2})
+
+	var ids []int64
+
+	for it.Next() {
+		ids = append(ids, it.Value().ID)
+	}
+
+	require.NoError(t, it.Err())
+	require.Equal(t, []int64{1, 2, 3, 4, 5}, ids)
+	require.Equal(t, 5, it.Metadata().TotalRecords)
+	require.False(t, it.Next())
+}
+
+func TestRuntime(t *testing.T) {
+	js, err := json.Marshal(Runtime(107))
+	require.NoError(t, err)
+	require.Equal(t, `"107 mins"`, string(js))
+
+	var r Runtime
+	require.NoError(t, json.Unmarshal([]byte(`"96 mins"`), &r))
+	require.Equal(t, Runtime(96), r)
+
+	require.ErrorIs(t, json.Unmarshal([]byte(`"96 minutes"`), &r), ErrInvalidRuntimeFormat)
+	require.ErrorIs(t, json.Unmarshal([]byte(`96`), &r), ErrInvalidRuntimeFormat)
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// APIError is an error response of the API.
+type APIError struct {
+	StatusCode int
+	// Code is the machine-readable code of the error, such as "not_found".
+	Code      string
+	Message   string
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("greenlight: %d %s", e.StatusCode, e.Message)
+	}
+
+	return fmt.Sprintf("greenlight: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// ValidationError is returned when the API rejects the request parameters,
+// with the messages by field.
+type ValidationError struct {
+	APIError
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+
+	for name, reason := range e.Fields {
+		fields = append(fields, name+": "+reason)
+	}
+
+	sort.Strings(fields)
+
+	return fmt.Sprintf("greenlight: validation failed: %s", strings.Join(fields, ", "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return &e.APIError
+}
+
+// EditConflictError is returned when a record was changed by someone else
+// since it was read, either detected by the API or by an If-Match
+// precondition. Fetch the record again and retry the change.
+type EditConflictError struct {
+	APIError
+}
+
+func (e *EditConflictError) Unwrap() error {
+	return &e.APIError
+}
+
+// IsNotFound reports whether err is a 404 Not Found response.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// newError reads an error response, sent either as an {"error": ...}
+// envelope or as a problem details document.
+func newError(res *http.Response) error {
+	apiErr := APIError{
+		StatusCode: res.StatusCode,
+		Message:    http.StatusText(res.StatusCode),
+		RequestID:  res.Header.Get("X-Request-Id"),
+	}
+
+	var fields map[string]string
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err == nil {
+		mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+
+		switch mediaType {
+		case "application/problem+json":
+			var problem struct {
+				Code          string `json:"code"`
+				Detail        string `json:"detail"`
+				RequestID     string `json:"requestId"`
+				InvalidParams []struct {
+					Name   string `json:"name"`
+					Reason string `json:"reason"`
+				} `json:"invalidParams"`
+			}
+
+			if json.Unmarshal(body, &problem) == nil {
+				apiErr.Code, apiErr.Message = problem.Code, problem.Detail
+
+				if problem.RequestID != "" {
+					apiErr.RequestID = problem.RequestID
+				}
+
+				for _, param := range problem.InvalidParams {
+					if fields == nil {
+						fields = make(map[string]string)
+					}
+
+					fields[param.Name] = param.Reason
+				}
+			}
+		case "application/json":
+			var env struct {
+				Error json.RawMessage `json:"error"`
+			}
+
+			if json.Unmarshal(body, &env) == nil && env.Error != nil {
+				if json.Unmarshal(env.Error, &apiErr.Message) != nil {
+					json.Unmarshal(env.Error, &fields)
+				}
+			}
+		}
+	}
+
+	switch {
+	case apiErr.Code == "validation_failed" || (apiErr.Code == "" && res.StatusCode == http.StatusUnprocessableEntity && fields != nil):
+		apiErr.Code = "validation_failed"
+		return &ValidationError{APIError: apiErr, Fields: fields}
+	case apiErr.Code == "edit_conflict" || apiErr.Code == "precondition_failed" || (apiErr.Code == "" && res.StatusCode == http.StatusPreconditionFailed):
+		return &EditConflictError{APIError: apiErr}
+	default:
+		return &apiErr
+	}
+}
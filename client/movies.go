@@ -0,0 +1,234 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRuntimeFormat is returned when decoding a runtime that is not
+// written as "<minutes> mins".
+var ErrInvalidRuntimeFormat = errors.New("invalid runtime format")
+
+// Runtime is the length of a movie in minutes, written as "107 mins" in
+// JSON.
+type Runtime int32
+
+func (r Runtime) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(fmt.Sprintf("%d mins", r))), nil
+}
+
+func (r *Runtime) UnmarshalJSON(jsonValue []byte) error {
+	unquoted, err := strconv.Unquote(string(jsonValue))
+	if err != nil {
+		return ErrInvalidRuntimeFormat
+	}
+
+	minutes, ok := strings.CutSuffix(unquoted, " mins")
+	if !ok {
+		return ErrInvalidRuntimeFormat
+	}
+
+	i, err := strconv.ParseInt(minutes, 10, 32)
+	if err != nil {
+		return ErrInvalidRuntimeFormat
+	}
+
+	*r = Runtime(i)
+
+	return nil
+}
+
+// Poster holds the URLs of the poster of a movie and its thumbnails.
+type Poster struct {
+	Original string `json:"original"`
+	Small    string `json:"small"`
+	Medium   string `json:"medium"`
+}
+
+// Credit is a person credited in a movie.
+type Credit struct {
+	PersonID     int64  `json:"personId"`
+	Name         string `json:"name"`
+	Role         string `json:"role"`
+	Character    string `json:"character,omitempty"`
+	BillingOrder int    `json:"billingOrder,omitempty"`
+}
+
+// Movie is a movie, as returned by the API.
+type Movie struct {
+	ID             int64             `json:"id"`
+	Title          string            `json:"title"`
+	Titles         map[string]string `json:"titles,omitempty"`
+	LocalizedTitle string            `json:"localizedTitle,omitempty"`
+	Genres         []string          `json:"genres,omitempty"`
+	Year           int32             `json:"year,omitempty"`
+	Runtime        Runtime           `json:"runtime,omitempty"`
+	Credits        []Credit          `json:"credits,omitempty"`
+	Poster         *Poster           `json:"poster,omitempty"`
+	Rating         float64           `json:"rating"`
+	RatingCount    int32             `json:"ratingCount"`
+	Version        int32             `json:"version"`
+	DeletedAt      *time.Time        `json:"deletedAt,omitempty"`
+}
+
+// MovieInput holds the fields of a new movie.
+type MovieInput struct {
+	Title   string   `json:"title"`
+	Genres  []string `json:"genres"`
+	Year    int32    `json:"year"`
+	Runtime Runtime  `json:"runtime"`
+	// Force creates the movie even when the API finds similar ones, which
+	// otherwise fails with a 409 Conflict "duplicate_movie" error.
+	Force bool `json:"-"`
+}
+
+// MovieUpdate holds the fields of a movie to change, nil ones are left
+// as they are.
+type MovieUpdate struct {
+	Title   *string  `json:"title,omitempty"`
+	Year    *int32   `json:"year,omitempty"`
+	Runtime *Runtime `json:"runtime,omitempty"`
+	Genres  []string `json:"genres,omitempty"`
+	// IfVersion, when not zero, only applies the update if the movie is
+	// still at this version, failing with an *EditConflictError otherwise.
+	IfVersion int32 `json:"-"`
+}
+
+// MovieFilter holds the criteria and the page of a movie list.
+type MovieFilter struct {
+	Title       string
+	Genres      []string
+	GenresAny   []string
+	GenresNot   []string
+	Director    string
+	InWatchlist bool
+	// Sort is one of id, title, year, runtime or rating, prefixed with "-"
+	// for a descending order.
+	Sort     string
+	Page     int
+	PageSize int
+}
+
+func (f MovieFilter) query() url.Values {
+	qs := make(url.Values)
+
+	setString(qs, "title", f.Title)
+	setString(qs, "genres", strings.Join(f.Genres, ","))
+	setString(qs, "genres_any", strings.Join(f.GenresAny, ","))
+	setString(qs, "genres_not", strings.Join(f.GenresNot, ","))
+	setString(qs, "director", f.Director)
+	setString(qs, "sort", f.Sort)
+	setInt(qs, "page", f.Page)
+	setInt(qs, "pageSize", f.PageSize)
+
+	if f.InWatchlist {
+		qs.Set("in_watchlist", "true")
+	}
+
+	return qs
+}
+
+func setString(qs url.Values, key, value string) {
+	if value != "" {
+		qs.Set(key, value)
+	}
+}
+
+func setInt(qs url.Values, key string, value int) {
+	if value != 0 {
+		qs.Set(key, strconv.Itoa(value))
+	}
+}
+
+// ListMovies returns a page of the movies matching the filter.
+func (c *Client) ListMovies(ctx context.Context, filter MovieFilter) ([]Movie, Metadata, error) {
+	var env struct {
+		Metadata Metadata `json:"metadata"`
+		Movies   []Movie  `json:"movies"`
+	}
+
+	err := c.do(ctx, request{method: http.MethodGet, path: "/v1/movies", query: filter.query()}, &env)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return env.Movies, env.Metadata, nil
+}
+
+// Movies returns an iterator over every movie matching the filter, from its
+// page on.
+func (c *Client) Movies(ctx context.Context, filter MovieFilter) *Iterator[Movie] {
+	return newIterator(ctx, filter.Page, func(ctx context.Context, page int) ([]Movie, Metadata, error) {
+		filter.Page = page
+		return c.ListMovies(ctx, filter)
+	})
+}
+
+// GetMovie returns the movie with the given ID.
+func (c *Client) GetMovie(ctx context.Context, id int64) (*Movie, error) {
+	var env struct {
+		Movie *Movie `json:"movie"`
+	}
+
+	err := c.do(ctx, request{method: http.MethodGet, path: moviePath(id)}, &env)
+	if err != nil {
+		return nil, err
+	}
+
+	return env.Movie, nil
+}
+
+// CreateMovie creates a movie.
+func (c *Client) CreateMovie(ctx context.Context, input MovieInput) (*Movie, error) {
+	var env struct {
+		Movie *Movie `json:"movie"`
+	}
+
+	req := request{method: http.MethodPost, path: "/v1/movies", body: input}
+
+	if input.Force {
+		req.query = url.Values{"force": {"true"}}
+	}
+
+	err := c.do(ctx, req, &env)
+	if err != nil {
+		return nil, err
+	}
+
+	return env.Movie, nil
+}
+
+// UpdateMovie changes the given fields of a movie and returns it updated.
+func (c *Client) UpdateMovie(ctx context.Context, id int64, update MovieUpdate) (*Movie, error) {
+	var env struct {
+		Movie *Movie `json:"movie"`
+	}
+
+	req := request{method: http.MethodPatch, path: moviePath(id), body: update}
+
+	if update.IfVersion != 0 {
+		req.header = http.Header{"If-Match": {fmt.Sprintf(`W/"%d"`, update.IfVersion)}}
+	}
+
+	err := c.do(ctx, req, &env)
+	if err != nil {
+		return nil, err
+	}
+
+	return env.Movie, nil
+}
+
+// DeleteMovie moves a movie to the trash.
+func (c *Client) DeleteMovie(ctx context.Context, id int64) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: moviePath(id)}, nil)
+}
+
+func moviePath(id int64) string {
+	return "/v1/movies/" + strconv.FormatInt(id, 10)
+}
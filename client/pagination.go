@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+)
+
+// Metadata describes a page of results.
+type Metadata struct {
+	CurrentPage  int `json:"currentPage,omitempty"`
+	PageSize     int `json:"pageSize,omitempty"`
+	FirstPage    int `json:"firstPage,omitempty"`
+	LastPage     int `json:"lastPage,omitempty"`
+	TotalRecords int `json:"totalRecords,omitempty"`
+}
+
+// Iterator walks through the records of a paginated list, fetching the
+// pages as they are reached:
+//
+//	it := c.Movies(ctx, client.MovieFilter{Genres: []string{"drama"}})
+//	for it.Next() {
+//		movie := it.Value()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	ctx   context.Context
+	fetch func(ctx context.Context, page int) ([]T, Metadata, error)
+
+	page     int
+	records  []T
+	index    int
+	metadata Metadata
+	done     bool
+	err      error
+}
+
+func newIterator[T any](ctx context.Context, page int, fetch func(ctx context.Context, page int) ([]T, Metadata, error)) *Iterator[T] {
+	if page < 1 {
+		page = 1
+	}
+
+	return &Iterator[T]{ctx: ctx, fetch: fetch, page: page, index: -1}
+}
+
+// Next advances to the next record, fetching the next page when needed, and
+// reports whether there is one.
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.index++
+
+	for it.index >= len(it.records) {
+		if it.done {
+			return false
+		}
+
+		it.records, it.metadata, it.err = it.fetch(it.ctx, it.page)
+		if it.err != nil {
+			return false
+		}
+
+		it.index = 0
+		it.page++
+		it.done = len(it.records) == 0 || it.metadata.CurrentPage >= it.metadata.LastPage
+	}
+
+	return true
+}
+
+// Value returns the current record.
+func (it *Iterator[T]) Value() T {
+	return it.records[it.index]
+}
+
+// Metadata returns the metadata of the last page fetched.
+func (it *Iterator[T]) Metadata() Metadata {
+	return it.metadata
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
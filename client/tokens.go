@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// AuthenticationToken is a bearer token authenticating requests.
+type AuthenticationToken struct {
+	Token  string    `json:"token"`
+	Expiry time.Time `json:"expiry"`
+}
+
+// CreateAuthenticationToken exchanges the credentials of a user for an
+// authentication token, without using it for the requests of the client.
+func (c *Client) CreateAuthenticationToken(ctx context.Context, email, password string) (*AuthenticationToken, error) {
+	var env struct {
+		Token *AuthenticationToken `json:"authenticationToken"`
+	}
+
+	body := map[string]string{"email": email, "password": password}
+
+	err := c.do(ctx, request{method: http.MethodPost, path: "/v1/tokens/authentication", body: body}, &env)
+	if err != nil {
+		return nil, err
+	}
+
+	return env.Token, nil
+}
+
+// Authenticate creates an authentication token for the credentials and
+// sends it with the following requests of the client.
+func (c *Client) Authenticate(ctx context.Context, email, password string) (*AuthenticationToken, error) {
+	token, err := c.CreateAuthenticationToken(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	c.SetToken(token.Token)
+
+	return token, nil
+}
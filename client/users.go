@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// User is a user account.
+type User struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Activated bool   `json:"activated"`
+	Version   int32  `json:"version"`
+}
+
+// RegisterUser creates a user account. The API emails the user an
+// activation token to pass to ActivateUser.
+func (c *Client) RegisterUser(ctx context.Context, name, email, password string) (*User, error) {
+	var env struct {
+		User *User `json:"user"`
+	}
+
+	body := map[string]string{"name": name, "email": email, "password": password}
+
+	err := c.do(ctx, request{method: http.MethodPost, path: "/v1/users", body: body}, &env)
+	if err != nil {
+		return nil, err
+	}
+
+	return env.User, nil
+}
+
+// ActivateUser activates the account the activation token was sent for.
+func (c *Client) ActivateUser(ctx context.Context, token string) (*User, error) {
+	var env struct {
+		User *User `json:"user"`
+	}
+
+	body := map[string]string{"token": token}
+
+	err := c.do(ctx, request{method: http.MethodPut, path: "/v1/users/activated", body: body}, &env)
+	if err != nil {
+		return nil, err
+	}
+
+	return env.User, nil
+}
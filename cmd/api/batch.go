@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/brGuirra/greenlight/internal/data"
+	"github.com/brGuirra/greenlight/internal/validator"
+)
+
+const batchAbortedMessage = "the operation was not applied because another operation in the batch failed"
+
+// batchResult is the outcome of a single operation of a batch. Status and
+// Error mirror what the equivalent single-movie endpoint would respond.
+type batchResult struct {
+	Index  int         `json:"index"`
+	Op     string      `json:"op"`
+	Status int         `json:"status"`
+	Movie  *data.Movie `json:"movie,omitempty"`
+	Error  any         `json:"error,omitempty"`
+}
+
+// batchMoviesHandler applies a list of create, update and delete operations.
+// In atomic mode nothing is written unless every operation succeeds,
+// otherwise each valid operation is applied independently.
+func (app *application) batchMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Atomic     bool `json:"atomic"`
+		Operations []struct {
+			Op      string        `json:"op"`
+			ID      int64         `json:"id"`
+			Version int32         `json:"version"`
+			Title   *string       `json:"title"`
+			Year    *int32        `json:"year"`
+			Runtime *data.Runtime `json:"runtime"`
+			Genres  []string      `json:"genres"`
+		} `json:"operations"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	v.Check(len(input.Operations) > 0, "operations", "must contain at least 1 operation")
+	v.Check(len(input.Operations) <= 100, "operations", "must not contain more than 100 operations")
+
+	for i, op := range input.Operations {
+		key := fmt.Sprintf("operations[%d]", i)
+
+		v.Check(validator.PermittedValue(op.Op, data.MovieOperationCreate, data.MovieOperationUpdate, data.MovieOperationDelete), key+".op", "must be one of create, update or delete")
+
+		if op.Op == data.MovieOperationUpdate || op.Op == data.MovieOperationDelete {
+			v.Check(op.ID > 0, key+".id", "must be provided")
+			v.Check(op.Version > 0, key+".version", "must be provided")
+		}
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	genres, err := app.models.Genres.Catalogue()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	results := make([]batchResult, len(input.Operations))
+
+	var (
+		ops     []data.MovieOperation
+		indexes []int
+		failed  bool
+	)
+
+	for i, op := range input.Operations {
+		results[i] = batchResult{Index: i, Op: op.Op}
+
+		movie := &data.Movie{}
+
+		if op.Op != data.MovieOperationCreate {
+			movie.ID = op.ID
+			movie.Version = op.Version
+		}
+
+		if op.Op == data.MovieOperationUpdate {
+			current, err := app.models.Movies.Get(op.ID)
+			if err != nil {
+				switch {
+				case errors.Is(err, data.ErrRecordNotFound):
+					results[i].Status, results[i].Error = http.StatusNotFound, notFoundMessage
+				default:
+					app.serverErrorResponse(w, r, err)
+					return
+				}
+
+				failed = true
+				continue
+			}
+
+			if current.Version != op.Version {
+				results[i].Status, results[i].Error = http.StatusConflict, editConflictMessage
+				failed = true
+				continue
+			}
+
+			*movie = current
+		}
+
+		if op.Op != data.MovieOperationDelete {
+			if op.Title != nil {
+				movie.Title = *op.Title
+			}
+
+			if op.Year != nil {
+				movie.Year = *op.Year
+			}
+
+			if op.Runtime != nil {
+				movie.Runtime = *op.Runtime
+			}
+
+			if op.Genres != nil {
+				movie.Genres = genres.Normalize(op.Genres)
+			}
+
+			v := validator.New()
+
+			if data.ValidateMovie(v, movie, genres); !v.Valid() {
+				results[i].Status, results[i].Error = http.StatusUnprocessableEntity, v.Errors
+				failed = true
+				continue
+			}
+		}
+
+		ops = append(ops, data.MovieOperation{Action: op.Op, Movie: movie})
+		indexes = append(indexes, i)
+	}
+
+	if input.Atomic && failed {
+		for _, i := range indexes {
+			results[i].Status, results[i].Error = http.StatusFailedDependency, batchAbortedMessage
+		}
+
+		app.writeBatchResults(w, r, results)
+		return
+	}
+
+	errs, err := app.models.Movies.Batch(ops, input.Atomic, app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	for j, i := range indexes {
+		result := &results[i]
+
+		switch {
+		case errs[j] == nil:
+			switch ops[j].Action {
+			case data.MovieOperationCreate:
+				result.Status, result.Movie = http.StatusCreated, ops[j].Movie
+			case data.MovieOperationUpdate:
+				result.Status, result.Movie = http.StatusOK, ops[j].Movie
+			default:
+				result.Status = http.StatusNoContent
+			}
+		case errors.Is(errs[j], data.ErrBatchAborted):
+			result.Status, result.Error = http.StatusFailedDependency, batchAbortedMessage
+		case errors.Is(errs[j], data.ErrRecordNotFound):
+			result.Status, result.Error = http.StatusNotFound, notFoundMessage
+		case errors.Is(errs[j], data.ErrEditConflict):
+			result.Status, result.Error = http.StatusConflict, editConflictMessage
+		default:
+			app.logError(r, errs[j])
+			result.Status, result.Error = http.StatusInternalServerError, serverErrorMessage
+		}
+	}
+
+	app.writeBatchResults(w, r, results)
+}
+
+func (app *application) writeBatchResults(w http.ResponseWriter, r *http.Request, results []batchResult) {
+	err := app.writeResponse(w, r, http.StatusOK, envelope{"results": results}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/data"
+)
+
+// movieETag returns the entity tag of a movie. It is derived from the
+// version, so it changes with every edit of the movie. Writes to what is
+// shown along with the movie, its rating, credits, titles and poster, bump
+// the version too. The tag is weak, as it is shared by every representation
+// of the version: each media type, language and projection of the fields.
+func movieETag(movie *data.Movie) string {
+	return fmt.Sprintf(`W/"%d"`, movie.Version)
+}
+
+// setMovieValidators sets the ETag and Last-Modified headers of a response
+// carrying the movie.
+func setMovieValidators(w http.ResponseWriter, movie *data.Movie) {
+	w.Header().Set("ETag", movieETag(movie))
+
+	if !movie.UpdatedAt.IsZero() {
+		w.Header().Set("Last-Modified", movie.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// notModified reports whether the client copy of the resource is still
+// current, according to the If-None-Match header or, when it is absent,
+// the If-Modified-Since header.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if values := r.Header.Values("If-None-Match"); len(values) > 0 {
+		return matchETag(values, etag, true)
+	}
+
+	if value := r.Header.Get("If-Modified-Since"); value != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(value)
+		if err != nil {
+			return false
+		}
+
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// checkIfMatch evaluates the If-Match header of an unsafe request against
+// the current entity tag of the resource. It sends a 412 Precondition
+// Failed response when no tag matches, or a 428 Precondition Required one
+// when the header is missing and the server is configured to require it,
+// and reports whether the request may go ahead. The tags are compared
+// weakly, as they are weak and stand for the version of the resource the
+// client edits, whatever representation it was read in.
+func (app *application) checkIfMatch(w http.ResponseWriter, r *http.Request, etag string) bool {
+	values := r.Header.Values("If-Match")
+
+	if len(values) == 0 {
+		if app.config.preconditions.required {
+			app.preconditionRequiredResponse(w, r)
+			return false
+		}
+
+		return true
+	}
+
+	if !matchETag(values, etag, true) {
+		w.Header().Set("ETag", etag)
+		app.preconditionFailedResponse(w, r)
+		return false
+	}
+
+	return true
+}
+
+// matchETag reports whether the comma-separated entity tags in the header
+// values include etag, or are the "*" wildcard. Weak tags only match when
+// weak comparison is allowed.
+func matchETag(values []string, etag string, weak bool) bool {
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+
+			if tag == "*" {
+				return true
+			}
+
+			if strings.HasPrefix(tag, "W/") {
+				if !weak {
+					continue
+				}
+
+				tag = strings.TrimPrefix(tag, "W/")
+			}
+
+			if tag == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
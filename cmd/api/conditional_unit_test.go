@@ -0,0 +1,23 @@
+//go:build unit
+// +build unit
+
+package main
+
+import (
+	"testing"
+
+	"github.com/brGuirra/greenlight/internal/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMovieETag(t *testing.T) {
+	etag := movieETag(&data.Movie{Version: 3})
+
+	require.Equal(t, `W/"3"`, etag)
+
+	require.True(t, matchETag([]string{`W/"3"`}, etag, true))
+	require.True(t, matchETag([]string{`"2", "3"`}, etag, true))
+	require.True(t, matchETag([]string{"*"}, etag, true))
+	require.False(t, matchETag([]string{`W/"2"`}, etag, true))
+	require.False(t, matchETag([]string{`W/"3"`}, etag, false), "weak tags never match strongly")
+}
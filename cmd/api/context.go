@@ -25,3 +25,19 @@ func (app *application) contextGetUser(r *http.Request) *data.User {
 
 	return user
 }
+
+const requestIDContextKey = contextKey("requestID")
+
+func (app *application) contextSetRequestID(r *http.Request, id string) *http.Request {
+	ctx := context.WithValue(r.Context(), requestIDContextKey, id)
+
+	return r.WithContext(ctx)
+}
+
+// contextGetRequestID returns the request id, or an empty string for
+// requests that did not go through the requestID middleware.
+func (app *application) contextGetRequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDContextKey).(string)
+
+	return id
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/brGuirra/greenlight/internal/render"
+)
+
+// errNotEncodable is returned by response encoders for envelopes that have
+// no representation in their media type.
+var errNotEncodable = errors.New("envelope cannot be encoded in the media type")
+
+// responseEncoder re-encodes a JSON envelope in a media type, setting any
+// header the representation needs.
+type responseEncoder struct {
+	mediaType string
+	encode    func(js []byte, header http.Header) ([]byte, error)
+}
+
+// responseEncoders lists the media types responses can be sent in, the
+// server preference first when several are equally acceptable. Aliases of
+// a media type follow the main ones, so they are only picked when named.
+var responseEncoders = []responseEncoder{
+	{mediaType: "application/json", encode: encodeJSON},
+	{mediaType: "application/xml", encode: encodeXML},
+	{mediaType: "application/msgpack", encode: encodeMessagePack},
+	{mediaType: "text/csv", encode: encodeCSV},
+	{mediaType: "text/xml", encode: encodeXML},
+	{mediaType: "application/x-msgpack", encode: encodeMessagePack},
+	{mediaType: "application/vnd.msgpack", encode: encodeMessagePack},
+}
+
+func responseMediaTypes() []string {
+	types := make([]string, len(responseEncoders))
+
+	for i := range responseEncoders {
+		types[i] = responseEncoders[i].mediaType
+	}
+
+	return types
+}
+
+// encodeResponse encodes the JSON envelope in the most preferred media type
+// of the Accept header able to represent it. A nil encoder is returned when
+// there is none.
+func encodeResponse(accept string, js []byte, header http.Header) (*responseEncoder, []byte, error) {
+	for _, mediaType := range render.Negotiate(accept, responseMediaTypes()) {
+		for i := range responseEncoders {
+			encoder := &responseEncoders[i]
+
+			if encoder.mediaType != mediaType {
+				continue
+			}
+
+			body, err := encoder.encode(js, header)
+			if errors.Is(err, errNotEncodable) {
+				break
+			}
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return encoder, body, nil
+		}
+	}
+
+	return nil, nil, nil
+}
+
+func encodeJSON(js []byte, header http.Header) ([]byte, error) {
+	return append(js, '\n'), nil
+}
+
+func encodeXML(js []byte, header http.Header) ([]byte, error) {
+	v, err := render.Decode(js)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	err = render.XML(&buf, "response", v)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
+
+func encodeMessagePack(js []byte, header http.Header) ([]byte, error) {
+	v, err := render.Decode(js)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	err = render.MessagePack(&buf, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encodeCSV writes the single list of the envelope as CSV rows. The other
+// members of the envelope, such as the pagination metadata, are sent as
+// headers: a "metadata" object with a "totalRecords" member becomes the
+// X-Metadata-Total-Records header.
+func encodeCSV(js []byte, header http.Header) ([]byte, error) {
+	v, err := render.Decode(js)
+	if err != nil {
+		return nil, err
+	}
+
+	env, ok := v.(render.Object)
+	if !ok {
+		return nil, errNotEncodable
+	}
+
+	var rows any
+	lists := 0
+
+	extra := make(http.Header)
+
+	for _, member := range env {
+		switch value := member.Value.(type) {
+		case []any:
+			rows = value
+			lists++
+		case render.Object:
+			for _, field := range value {
+				cell, ok := csvHeaderValue(field.Value)
+				if !ok {
+					return nil, errNotEncodable
+				}
+
+				extra.Set("X-"+headerName(member.Key)+"-"+headerName(field.Key), cell)
+			}
+		default:
+			cell, ok := csvHeaderValue(value)
+			if !ok {
+				return nil, errNotEncodable
+			}
+
+			extra.Set("X-"+headerName(member.Key), cell)
+		}
+	}
+
+	if lists != 1 {
+		return nil, errNotEncodable
+	}
+
+	var buf bytes.Buffer
+
+	err = render.CSV(&buf, rows)
+	if err != nil {
+		if errors.Is(err, render.ErrNotTabular) {
+			return nil, errNotEncodable
+		}
+
+		return nil, err
+	}
+
+	for key, value := range extra {
+		header[key] = value
+	}
+
+	return buf.Bytes(), nil
+}
+
+// csvHeaderValue formats a scalar sent as a header of a CSV response.
+func csvHeaderValue(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case interface{ String() string }:
+		return v.String(), true
+	case bool:
+		if v {
+			return "true", true
+		}
+
+		return "false", true
+	default:
+		return "", false
+	}
+}
+
+// headerName turns a camelCase key into header words, "totalRecords"
+// becoming "Total-Records".
+func headerName(key string) string {
+	var b strings.Builder
+
+	for i, r := range key {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('-')
+		}
+
+		b.WriteRune(r)
+	}
+
+	return http.CanonicalHeaderKey(b.String())
+}
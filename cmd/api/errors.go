@@ -3,19 +3,50 @@ package main
 import (
 	"fmt"
 	"net/http"
+
+	"github.com/brGuirra/greenlight/internal/data"
+)
+
+// Messages shared by the error responses and the per-operation results of
+// batchMoviesHandler.
+const (
+	serverErrorMessage  = "the server encountered a problem and could not process your request"
+	notFoundMessage     = "the requested resource could not be found"
+	editConflictMessage = "unable to update the record due to an edit conflict, please try again"
 )
 
 func (app *application) logError(r *http.Request, err error) {
 	app.logger.PrintError(err, map[string]string{
+		"requestId":     app.contextGetRequestID(r),
 		"requestMethod": r.Method,
 		"requestURL":    r.URL.String(),
 	})
 }
 
-func (app *application) errorResponse(w http.ResponseWriter, r *http.Request, status int, message any) {
-	env := envelope{"error": message}
+// errorResponse sends an error with a machine-readable code. The message is
+// a string, or a map of field names to messages for validation errors.
+func (app *application) errorResponse(w http.ResponseWriter, r *http.Request, status int, code string, message any) {
+	app.errorResponseWith(w, r, status, code, message, nil)
+}
+
+// errorResponseWith sends an error along with extra members, either as the
+// {"error": message} envelope or, when enabled or asked for in the Accept
+// header, as an RFC 7807 Problem Details document.
+func (app *application) errorResponseWith(w http.ResponseWriter, r *http.Request, status int, code string, message any, extra envelope) {
+	var err error
+
+	if app.wantsProblemDetails(r) {
+		err = app.writeProblem(w, r, status, code, message, extra)
+	} else {
+		env := envelope{"error": message}
+
+		for key, value := range extra {
+			env[key] = value
+		}
+
+		err = app.writeResponse(w, r, status, env, nil)
+	}
 
-	err := app.writeJSON(w, status, env, nil)
 	if err != nil {
 		app.logError(r, err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -25,60 +56,103 @@ func (app *application) errorResponse(w http.ResponseWriter, r *http.Request, st
 func (app *application) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
 	app.logError(r, err)
 
-	message := "the server encountered a problem and could not process your request"
-
-	app.errorResponse(w, r, http.StatusInternalServerError, message)
+	app.errorResponse(w, r, http.StatusInternalServerError, "server_error", serverErrorMessage)
 }
 
 func (app *application) notFoundResponse(w http.ResponseWriter, r *http.Request) {
-	message := "the requested resource could not be found"
-
-	app.errorResponse(w, r, http.StatusNotFound, message)
+	app.errorResponse(w, r, http.StatusNotFound, "not_found", notFoundMessage)
 }
 
 func (app *application) methodNotAllowedResponse(w http.ResponseWriter, r *http.Request) {
 	message := fmt.Sprintf("the %s method not supported for this resource", r.Method)
 
-	app.errorResponse(w, r, http.StatusMethodNotAllowed, message)
+	app.errorResponse(w, r, http.StatusMethodNotAllowed, "method_not_allowed", message)
 }
 
 func (app *application) badRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
-	app.errorResponse(w, r, http.StatusBadRequest, err.Error())
+	app.errorResponse(w, r, http.StatusBadRequest, "bad_request", err.Error())
 }
 
 func (app *application) editConflictResponse(w http.ResponseWriter, r *http.Request) {
-	message := "unable to update the record due to an edit conflict, please try again"
-	app.errorResponse(w, r, http.StatusConflict, message)
+	app.errorResponse(w, r, http.StatusConflict, "edit_conflict", editConflictMessage)
+}
+
+func (app *application) patchTestFailedResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.errorResponse(w, r, http.StatusConflict, "patch_test_failed", err.Error())
+}
+
+func (app *application) unprocessablePatchResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.errorResponse(w, r, http.StatusUnprocessableEntity, "unprocessable_patch", err.Error())
+}
+
+func (app *application) preconditionFailedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the resource has changed since it was last read, fetch it again and retry"
+	app.errorResponse(w, r, http.StatusPreconditionFailed, "precondition_failed", message)
+}
+
+func (app *application) preconditionRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	message := "this request must be made conditional with an If-Match header"
+	app.errorResponse(w, r, http.StatusPreconditionRequired, "precondition_required", message)
+}
+
+func (app *application) idempotencyKeyInUseResponse(w http.ResponseWriter, r *http.Request) {
+	message := "a request with this idempotency key is still being processed, please try again later"
+	app.errorResponse(w, r, http.StatusConflict, "idempotency_key_in_use", message)
+}
+
+func (app *application) idempotencyKeyFailedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the request sent with this idempotency key was processed but its response could not be stored, please check its outcome before retrying with a new key"
+	app.errorResponse(w, r, http.StatusConflict, "idempotency_key_failed", message)
+}
+
+func (app *application) idempotencyKeyMismatchResponse(w http.ResponseWriter, r *http.Request) {
+	message := "this idempotency key was already used for a different request"
+	app.errorResponse(w, r, http.StatusUnprocessableEntity, "idempotency_key_mismatch", message)
 }
 
 func (app *application) rateLimitExceededResponse(w http.ResponseWriter, r *http.Request) {
 	message := "rate limit exceeded"
-	app.errorResponse(w, r, http.StatusTooManyRequests, message)
+	app.errorResponse(w, r, http.StatusTooManyRequests, "rate_limit_exceeded", message)
 }
 
 func (app *application) invalidCredentialsResponse(w http.ResponseWriter, r *http.Request) {
 	message := "invalid authentication credentials"
-	app.errorResponse(w, r, http.StatusUnauthorized, message)
+	app.errorResponse(w, r, http.StatusUnauthorized, "invalid_credentials", message)
 }
 
 func (app *application) invalidAuthenticationTokenResponse(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("WWW-Authenticate", "Bearer")
 
 	message := "invalid or missing authentication token"
-	app.errorResponse(w, r, http.StatusUnauthorized, message)
+	app.errorResponse(w, r, http.StatusUnauthorized, "invalid_authentication_token", message)
 }
 
 func (app *application) authenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
 	message := "you must be authenticated to access this resource"
-	app.errorResponse(w, r, http.StatusUnauthorized, message)
+	app.errorResponse(w, r, http.StatusUnauthorized, "authentication_required", message)
 }
 
 func (app *application) inactiveAccountResponse(w http.ResponseWriter, r *http.Request) {
 	message := "your user account must be activated to access this resource"
-	app.errorResponse(w, r, http.StatusForbidden, message)
+	app.errorResponse(w, r, http.StatusForbidden, "inactive_account", message)
 }
 
 func (app *application) notPermittedResponse(w http.ResponseWriter, r *http.Request) {
 	message := "your user account does not have the necessary permissions to access this resource"
-	app.errorResponse(w, r, http.StatusForbidden, message)
+	app.errorResponse(w, r, http.StatusForbidden, "not_permitted", message)
+}
+
+func (app *application) notAcceptableResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the resource cannot be represented in any of the accepted media types, supported types are application/json, application/xml, application/msgpack and, for lists, text/csv"
+	app.errorResponse(w, r, http.StatusNotAcceptable, "not_acceptable", message)
+}
+
+func (app *application) unsupportedMediaTypeResponse(w http.ResponseWriter, r *http.Request) {
+	message := fmt.Sprintf("the %q content type is not supported for this resource", r.Header.Get("Content-Type"))
+	app.errorResponse(w, r, http.StatusUnsupportedMediaType, "unsupported_media_type", message)
+}
+
+func (app *application) duplicateMovieResponse(w http.ResponseWriter, r *http.Request, candidates []data.Movie) {
+	message := "a similar movie already exists, repeat the request with force=true to create it anyway"
+	app.errorResponseWith(w, r, http.StatusConflict, "duplicate_movie", message, envelope{"candidates": candidates})
 }
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/data"
+	"github.com/brGuirra/greenlight/internal/validator"
+	"github.com/lib/pq"
+)
+
+const (
+	// movieEventsPageSize is how many events are read from the log at once.
+	movieEventsPageSize = 100
+
+	// movieEventsBuffer is how many events a stream may fall behind by
+	// before it is closed, the client then resumes from the log.
+	movieEventsBuffer = 64
+
+	movieEventsHeartbeat = 15 * time.Second
+
+	movieEventsTrimInterval = time.Minute
+
+	movieEventsRetryDelay    = time.Second
+	movieEventsMaxRetryDelay = time.Minute
+)
+
+// eventBroker fans the movie events out to the open event streams of this
+// instance.
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan data.MovieEvent]struct{}
+	closed      bool
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: make(map[chan data.MovieEvent]struct{})}
+}
+
+// subscribe returns a channel receiving the events published from now on,
+// and a function to call once done with it. The channel is closed when the
+// subscriber falls too far behind or the broker is closed.
+func (b *eventBroker) subscribe() (<-chan data.MovieEvent, func()) {
+	ch := make(chan data.MovieEvent, movieEventsBuffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	b.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *eventBroker) publish(movieEvent data.MovieEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- movieEvent:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// close ends every subscription, so that open streams do not hold up the
+// server shutdown.
+func (b *eventBroker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// listenMovieEvents relays the movie events logged by every instance to the
+// broker. Postgres notifies it of each new event, it then reads from the log
+// what it has not relayed yet, which also covers the events missed while the
+// connection was being reestablished. Setting up the listener is retried
+// until it succeeds, and the log is read again on every keepalive, so that
+// an unavailable database only delays the events. It stops once the context
+// is done.
+func (app *application) listenMovieEvents(ctx context.Context) {
+	reportProblem := func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			app.logger.PrintError(err, nil)
+		}
+	}
+
+	listener := pq.NewListener(app.config.db.dsn, time.Second, time.Minute, reportProblem)
+
+	// Closing the listener also ends a Listen call waiting for the
+	// connection.
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	var lastID int64
+
+	err := app.retry(ctx, func() error {
+		err := listener.Listen(data.MovieEventsChannel)
+		if err != nil && !errors.Is(err, pq.ErrChannelAlreadyOpen) {
+			return err
+		}
+
+		lastID, err = app.models.MovieEvents.LatestID()
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(90 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-listener.Notify:
+		case <-ticker.C:
+			go listener.Ping()
+		}
+
+		for {
+			movieEvents, err := app.models.MovieEvents.GetAfter(lastID, movieEventsPageSize)
+			if err != nil {
+				app.logger.PrintError(err, nil)
+				break
+			}
+
+			for _, movieEvent := range movieEvents {
+				app.events.publish(movieEvent)
+				lastID = movieEvent.ID
+			}
+
+			if len(movieEvents) < movieEventsPageSize {
+				break
+			}
+		}
+	}
+}
+
+// retry calls fn until it succeeds, waiting after each failure for twice as
+// long as after the previous one, from movieEventsRetryDelay up to
+// movieEventsMaxRetryDelay. It gives up with the context error once the
+// context is done.
+func (app *application) retry(ctx context.Context, fn func() error) error {
+	delay := movieEventsRetryDelay
+
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		app.logger.PrintError(err, nil)
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if delay > movieEventsMaxRetryDelay {
+			delay = movieEventsMaxRetryDelay
+		}
+	}
+}
+
+// trimMovieEvents periodically trims the movie event log to its configured
+// size, until the context is done.
+func (app *application) trimMovieEvents(ctx context.Context) {
+	ticker := time.NewTicker(movieEventsTrimInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := app.models.MovieEvents.Trim(app.config.events.logSize)
+		if err != nil {
+			app.logger.PrintError(err, nil)
+		}
+	}
+}
+
+// streamMovieEventsHandler streams the movie events as Server-Sent Events.
+// Clients reconnecting with a Last-Event-ID header first get the events they
+// missed, as far as the log still holds them.
+func (app *application) streamMovieEventsHandler(w http.ResponseWriter, r *http.Request) {
+	var lastEventID int64
+
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		v := validator.New()
+
+		id, err := strconv.ParseInt(header, 10, 64)
+		v.Check(err == nil && id >= 0, "Last-Event-ID", "must be a positive integer")
+
+		if !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		lastEventID = id
+	}
+
+	rc := http.NewResponseController(w)
+
+	// The stream outlives the server write timeout.
+	err := rc.SetWriteDeadline(time.Time{})
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Subscribe before reading the log, so that no event falls in between.
+	movieEvents, unsubscribe := app.events.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	w.WriteHeader(http.StatusOK)
+
+	if lastEventID > 0 {
+		for {
+			missed, err := app.models.MovieEvents.GetAfter(lastEventID, movieEventsPageSize)
+			if err != nil {
+				app.logError(r, err)
+				return
+			}
+
+			for _, movieEvent := range missed {
+				err = writeServerSentEvent(w, movieEvent)
+				if err != nil {
+					return
+				}
+
+				lastEventID = movieEvent.ID
+			}
+
+			if len(missed) < movieEventsPageSize {
+				break
+			}
+		}
+	}
+
+	err = rc.Flush()
+	if err != nil {
+		app.logError(r, err)
+		return
+	}
+
+	heartbeat := time.NewTicker(movieEventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+		case movieEvent, ok := <-movieEvents:
+			if !ok {
+				return
+			}
+
+			if movieEvent.ID <= lastEventID {
+				continue
+			}
+
+			err = writeServerSentEvent(w, movieEvent)
+			lastEventID = movieEvent.ID
+		}
+
+		if err == nil {
+			err = rc.Flush()
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writeServerSentEvent writes the event in the text/event-stream format. The
+// payload is JSON, which Postgres renders on a single line, so it fits on the
+// data line.
+func writeServerSentEvent(w io.Writer, movieEvent data.MovieEvent) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", movieEvent.ID, movieEvent.Event, movieEvent.Payload)
+	return err
+}
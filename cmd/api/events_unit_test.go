@@ -0,0 +1,107 @@
+//go:build unit
+// +build unit
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brGuirra/greenlight/internal/data"
+	"github.com/brGuirra/greenlight/internal/jsonlog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBroker(t *testing.T) {
+	broker := newEventBroker()
+
+	fast, unsubscribeFast := broker.subscribe()
+	defer unsubscribeFast()
+
+	slow, unsubscribeSlow := broker.subscribe()
+	defer unsubscribeSlow()
+
+	for i := int64(1); i <= movieEventsBuffer; i++ {
+		broker.publish(data.MovieEvent{ID: i, Event: data.EventMovieCreated})
+		require.Equal(t, i, (<-fast).ID)
+	}
+
+	broker.publish(data.MovieEvent{ID: movieEventsBuffer + 1, Event: data.EventMovieCreated})
+	require.Equal(t, int64(movieEventsBuffer+1), (<-fast).ID)
+
+	// The slow subscriber did not read anything, it is dropped once full.
+	for i := 0; i < movieEventsBuffer; i++ {
+		_, ok := <-slow
+		require.True(t, ok)
+	}
+
+	_, ok := <-slow
+	require.False(t, ok, "a subscriber falling behind is closed")
+
+	broker.close()
+
+	_, ok = <-fast
+	require.False(t, ok, "subscribers are closed with the broker")
+
+	late, unsubscribeLate := broker.subscribe()
+	defer unsubscribeLate()
+
+	_, ok = <-late
+	require.False(t, ok, "subscribing to a closed broker returns a closed channel")
+}
+
+func TestWriteServerSentEvent(t *testing.T) {
+	var buf bytes.Buffer
+
+	movieEvent := data.MovieEvent{ID: 7, Event: data.EventMovieDeleted, Payload: json.RawMessage(`{"id": 3}`)}
+
+	require.NoError(t, writeServerSentEvent(&buf, movieEvent))
+	require.Equal(t, "id: 7\nevent: movie.deleted\ndata: {\"id\": 3}\n\n", buf.String())
+}
+
+func TestMetricsResponseWriterFlush(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	mw := newMetricsResponseWriter(rec)
+
+	var w http.ResponseWriter = mw
+
+	flusher, ok := w.(http.Flusher)
+	require.True(t, ok)
+
+	flusher.Flush()
+
+	require.True(t, rec.Flushed)
+	require.Equal(t, http.StatusOK, mw.statusCode)
+}
+
+func TestRetry(t *testing.T) {
+	app := &application{logger: jsonlog.New(io.Discard, jsonlog.LevelOff)}
+
+	calls := 0
+
+	err := app.retry(context.Background(), func() error {
+		calls++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls = 0
+
+	err = app.retry(ctx, func() error {
+		calls++
+		return errors.New("connection refused")
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 1, calls, "a failure is not retried once the context is done")
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/data"
+	"github.com/brGuirra/greenlight/internal/validator"
+)
+
+// exportFlushInterval is the number of movies written between flushes of the
+// response, so clients start receiving data while the export runs.
+const exportFlushInterval = 500
+
+var exportCSVColumns = []string{"id", "title", "year", "runtime", "genres", "rating", "rating_count", "version"}
+
+// exportMoviesHandler streams every movie matching the same criteria as
+// listMoviesHandler, as NDJSON or CSV depending on the "format" parameter.
+func (app *application) exportMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		data.MovieQuery
+		Format string
+		Sort   string
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.MovieQuery = app.readMovieQuery(r, qs, v)
+	input.Format = app.readString(qs, "format", data.ImportFormatNDJSON)
+	input.Sort = app.readString(qs, "sort", "id")
+
+	v.Check(validator.PermittedValue(input.Format, data.ImportFormatNDJSON, data.ImportFormatCSV), "format", "must be one of ndjson or csv")
+	v.Check(validator.PermittedValue(input.Sort, data.MoviesSortSafeList...), "sort", "invalid sort value")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err := app.normalizeMovieQuery(&input.MovieQuery)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	filters := data.Filters{Sort: input.Sort, SortSafeList: data.MoviesSortSafeList}
+
+	rc := http.NewResponseController(w)
+
+	// The export can outlast the server write timeout, client disconnects
+	// are noticed through the request context instead.
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	out := &exportWriter{w: w}
+	buf := bufio.NewWriter(out)
+
+	var write func(movie *data.Movie) error
+
+	switch input.Format {
+	case data.ImportFormatCSV:
+		w.Header().Set("Content-Type", "text/csv")
+
+		cw := csv.NewWriter(buf)
+
+		err = cw.Write(exportCSVColumns)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		write = func(movie *data.Movie) error {
+			err := cw.Write([]string{
+				strconv.FormatInt(movie.ID, 10),
+				movie.Title,
+				strconv.FormatInt(int64(movie.Year), 10),
+				strconv.FormatInt(int64(movie.Runtime), 10),
+				strings.Join(movie.Genres, "|"),
+				strconv.FormatFloat(movie.Rating, 'f', 2, 64),
+				strconv.FormatInt(int64(movie.RatingCount), 10),
+				strconv.FormatInt(int64(movie.Version), 10),
+			})
+			if err != nil {
+				return err
+			}
+
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+
+		write = func(movie *data.Movie) error {
+			js, err := json.Marshal(movie)
+			if err != nil {
+				return err
+			}
+
+			_, err = buf.Write(append(js, '\n'))
+			return err
+		}
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="movies.`+input.Format+`"`)
+
+	count := 0
+
+	err = app.models.Movies.Export(r.Context(), input.MovieQuery, filters, func(movie *data.Movie) error {
+		err := write(movie)
+		if err != nil {
+			return err
+		}
+
+		count++
+
+		if count%exportFlushInterval == 0 {
+			err = buf.Flush()
+			if err != nil {
+				return err
+			}
+
+			return rc.Flush()
+		}
+
+		return nil
+	})
+
+	if err == nil {
+		err = buf.Flush()
+	}
+
+	switch {
+	case err == nil:
+		return
+	case errors.Is(r.Context().Err(), context.Canceled):
+		// The client went away, there is nobody left to report the error to.
+		return
+	case !out.written:
+		// Nothing has reached the client yet, so a proper error response
+		// can still be sent.
+		w.Header().Del("Content-Disposition")
+		app.serverErrorResponse(w, r, err)
+	default:
+		app.logError(r, err)
+	}
+}
+
+// exportWriter records whether any part of the export reached the client,
+// after which errors can no longer be reported with an error response.
+type exportWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (ew *exportWriter) Write(b []byte) (int, error) {
+	ew.written = true
+	return ew.w.Write(b)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/brGuirra/greenlight/internal/data"
+	"github.com/brGuirra/greenlight/internal/validator"
+)
+
+// readMovieFieldset reads and validates the fields and include query
+// string parameters of a movie endpoint. The given include is used when the
+// parameter is absent.
+func (app *application) readMovieFieldset(qs url.Values, defaultInclude []string, v *validator.Validator) (fields, include []string) {
+	fields = app.readCSV(qs, "fields", []string{})
+	include = app.readCSV(qs, "include", defaultInclude)
+
+	data.ValidateFieldset(v, "fields", fields, data.MoviesFieldsSafeList)
+	data.ValidateFieldset(v, "include", include, data.MoviesIncludeSafeList)
+
+	return fields, include
+}
+
+// selectFields returns the JSON object v marshals to, reduced to its id
+// and the given keys.
+func selectFields(v any, keys []string) (map[string]json.RawMessage, error) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]json.RawMessage
+
+	err = json.Unmarshal(js, &object)
+	if err != nil {
+		return nil, err
+	}
+
+	for key := range object {
+		if key != "id" && !validator.PermittedValue(key, keys...) {
+			delete(object, key)
+		}
+	}
+
+	return object, nil
+}
@@ -10,39 +10,65 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/brGuirra/greenlight/internal/data"
 	"github.com/brGuirra/greenlight/internal/validator"
 	"github.com/julienschmidt/httprouter"
 )
 
+// jsonMaxBytes is the largest JSON request body accepted.
+const jsonMaxBytes = 1_048_576
+
 type envelope map[string]any
 
 func (app *application) readIDParam(r *http.Request) (int64, error) {
+	return app.readNamedIDParam(r, "id")
+}
+
+func (app *application) readNamedIDParam(r *http.Request, name string) (int64, error) {
 	params := httprouter.ParamsFromContext(r.Context())
 
-	id, err := strconv.ParseInt(params.ByName("id"), 10, 64)
+	id, err := strconv.ParseInt(params.ByName(name), 10, 64)
 	if err != nil {
-		return 0, errors.New("invalid id parameter")
+		return 0, fmt.Errorf("invalid %s parameter", name)
 	}
 
 	return id, nil
 }
 
-func (app *application) writeJSON(w http.ResponseWriter, status int, data envelope, headers http.Header) error {
+// writeResponse sends the envelope in the media type negotiated from the
+// Accept header, see responseEncoders. When none of the accepted types can
+// represent the envelope a 406 Not Acceptable response is sent instead,
+// except for error responses and unsafe requests, whose changes have been
+// made already, which are sent as JSON regardless.
+func (app *application) writeResponse(w http.ResponseWriter, r *http.Request, status int, data envelope, headers http.Header) error {
 	js, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
 
-	js = append(js, '\n')
+	encoder, body, err := encodeResponse(r.Header.Get("Accept"), js, w.Header())
+	if err != nil {
+		return err
+	}
+
+	if encoder == nil {
+		if status < 400 && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+			app.notAcceptableResponse(w, r)
+			return nil
+		}
+
+		encoder, body = &responseEncoders[0], append(js, '\n')
+	}
 
 	for key, value := range headers {
 		w.Header()[key] = value
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("Content-Type", encoder.mediaType)
 	w.WriteHeader(status)
 
-	_, err = w.Write(js)
+	_, err = w.Write(body)
 	if err != nil {
 		return err
 	}
@@ -51,8 +77,7 @@ func (app *application) writeJSON(w http.ResponseWriter, status int, data envelo
 }
 
 func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
-	maxBytes := 1_048_576
-	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
+	r.Body = http.MaxBytesReader(w, r.Body, jsonMaxBytes)
 
 	dec := json.NewDecoder(r.Body)
 	dec.DisallowUnknownFields()
@@ -106,7 +131,7 @@ func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any
 }
 
 func (app *application) failedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]string) {
-	app.errorResponse(w, r, http.StatusUnprocessableEntity, errors)
+	app.errorResponse(w, r, http.StatusUnprocessableEntity, "validation_failed", errors)
 }
 
 func (app *application) noContentResponse(w http.ResponseWriter) {
@@ -149,6 +174,30 @@ func (app *application) readInt(qs url.Values, key string, defaultValue int, v *
 	return i
 }
 
+func (app *application) readBool(qs url.Values, key string, defaultValue bool, v *validator.Validator) bool {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean value")
+		return defaultValue
+	}
+
+	return b
+}
+
+// readLanguages returns the languages of the Accept-Language header in
+// order of preference, and marks the response as varying on the header.
+func (app *application) readLanguages(w http.ResponseWriter, r *http.Request) []string {
+	w.Header().Add("Vary", "Accept-Language")
+
+	return data.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+}
+
 func (app *application) background(fn func()) {
 	app.wg.Add(1)
 
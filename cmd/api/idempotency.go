@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/data"
+	"github.com/brGuirra/greenlight/internal/validator"
+
+	"github.com/tomasen/realip"
+)
+
+const (
+	// idempotencyLockTimeout is how long a request holds its idempotency
+	// key before an identical retry may take it over, in case the instance
+	// handling it went away. It exceeds the server write timeout.
+	idempotencyLockTimeout = time.Minute
+
+	idempotencyPurgeInterval = time.Hour
+)
+
+// idempotencyRecorder passes a response through while keeping a copy of
+// its status and body.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(statusCode int) {
+	if rec.status == 0 {
+		rec.status = statusCode
+	}
+
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	rec.body.Write(b)
+
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// idempotency makes unsafe requests sent with an Idempotency-Key header
+// safe to retry. The first response for a key is stored, for the user or,
+// for anonymous requests, the client address, and replayed to retries of
+// the same request. Reusing the key for a different request is rejected
+// with a 422, and retrying while the first request is still being processed
+// with a 409, as is retrying a request whose response could not be stored.
+// Server errors are not stored, so those requests can be retried with the
+// same key. It wraps the handlers of the routes, after the
+// permission checks, so that the body of a request, read up to maxBytes to
+// identify it, is only read once it is allowed. Routes answering with
+// secrets, such as authentication tokens, must not be wrapped, as the
+// responses are stored as they are.
+func (app *application) idempotency(maxBytes int64, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		v := validator.New()
+
+		data.ValidateIdempotencyKey(v, key)
+
+		if !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+		if err != nil {
+			var maxBytesError *http.MaxBytesError
+
+			switch {
+			case errors.As(err, &maxBytesError):
+				app.badRequestResponse(w, r, fmt.Errorf("body must not be longer than %d bytes", maxBytesError.Limit))
+			default:
+				app.badRequestResponse(w, r, err)
+			}
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		owner := idempotencyOwner(r, app.contextGetUser(r))
+		hash := idempotencyRequestHash(r, body)
+
+		record, err := app.models.IdempotencyKeys.Reserve(owner, key, hash, app.config.idempotency.ttl, idempotencyLockTimeout)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if record != nil {
+			switch {
+			case !bytes.Equal(record.RequestHash, hash):
+				app.idempotencyKeyMismatchResponse(w, r)
+			case record.Failed:
+				app.idempotencyKeyFailedResponse(w, r)
+			case record.InFlight():
+				app.idempotencyKeyInUseResponse(w, r)
+			default:
+				for name, values := range record.Header {
+					w.Header()[name] = values
+				}
+
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(record.Status)
+				w.Write(record.Body)
+			}
+			return
+		}
+
+		processed := false
+
+		// Free the key when the request panics or fails with a server
+		// error, a retry can then be processed anew.
+		defer func() {
+			if !processed {
+				err := app.models.IdempotencyKeys.Release(owner, key)
+				if err != nil {
+					app.logError(r, err)
+				}
+			}
+		}()
+
+		rec := &idempotencyRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		if rec.status >= 500 {
+			return
+		}
+
+		header := w.Header().Clone()
+		header.Del("X-Request-Id")
+
+		err = app.models.IdempotencyKeys.Complete(&data.IdempotencyKey{
+			Owner:  owner,
+			Key:    key,
+			Status: rec.status,
+			Header: header,
+			Body:   rec.body.Bytes(),
+		})
+		// The request went through, so the key is kept even when its
+		// response cannot be stored: it is marked failed, retries are then
+		// rejected rather than repeating the request.
+		processed = true
+
+		if err != nil {
+			app.logError(r, err)
+
+			err = app.models.IdempotencyKeys.Fail(owner, key)
+			if err != nil {
+				app.logError(r, err)
+			}
+		}
+	}
+}
+
+// idempotencyMaxBytes is the size up to which the bodies of the routes
+// requiring a permission are read to identify them, that of the largest
+// bodies they accept, movie imports and posters.
+func (app *application) idempotencyMaxBytes() int64 {
+	if app.posterBodyLimit() > app.config.imports.maxBytes {
+		return app.posterBodyLimit()
+	}
+
+	return app.config.imports.maxBytes
+}
+
+// idempotencyOwner returns the scope of the idempotency keys of a request:
+// the user, or the client address for anonymous requests.
+func idempotencyOwner(r *http.Request, user *data.User) string {
+	if user.IsAnonymous() {
+		return "client:" + realip.FromRequest(r)
+	}
+
+	return "user:" + strconv.FormatInt(user.ID, 10)
+}
+
+// idempotencyRequestHash identifies a request by its method, URL, body and
+// the media type of the body.
+func idempotencyRequestHash(r *http.Request, body []byte) []byte {
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%s %s\n%s\n", r.Method, r.URL.RequestURI(), r.Header.Get("Content-Type"))
+	h.Write(body)
+
+	return h.Sum(nil)
+}
+
+// purgeIdempotencyKeys periodically removes the expired idempotency keys.
+func (app *application) purgeIdempotencyKeys(ctx context.Context) {
+	ticker := time.NewTicker(idempotencyPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := app.models.IdempotencyKeys.DeleteExpired()
+		if err != nil {
+			app.logger.PrintError(err, nil)
+			continue
+		}
+
+		if purged > 0 {
+			app.logger.PrintInfo("purged expired idempotency keys", map[string]string{
+				"count": strconv.FormatInt(purged, 10),
+			})
+		}
+	}
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/data"
+	"github.com/brGuirra/greenlight/internal/validator"
+)
+
+const (
+	// importBatchSize is the number of movies inserted per transaction.
+	importBatchSize = 500
+	// importSyncLimit is the largest import processed within the request,
+	// bigger ones run as a background job.
+	importSyncLimit = 100
+	// importStaleAfter is how long a pending or running import may go
+	// without being saved before it is considered interrupted. A batch
+	// takes at most 30 seconds.
+	importStaleAfter         = 5 * time.Minute
+	importStaleCheckInterval = time.Minute
+)
+
+var importCSVColumns = []string{"title", "year", "runtime", "genres"}
+
+func (app *application) importMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var format string
+
+	switch mediaType {
+	case "text/csv":
+		format = data.ImportFormatCSV
+	case "application/x-ndjson", "application/ndjson":
+		format = data.ImportFormatNDJSON
+	default:
+		app.unsupportedMediaTypeResponse(w, r)
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, app.config.imports.maxBytes)
+
+	var (
+		rows []data.ImportRow
+		err  error
+	)
+
+	switch format {
+	case data.ImportFormatCSV:
+		rows, err = parseCSVImport(body)
+	default:
+		rows, err = parseNDJSONImport(body)
+	}
+
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &maxBytesError):
+			app.badRequestResponse(w, r, fmt.Errorf("body must not be longer than %d bytes", maxBytesError.Limit))
+		default:
+			app.badRequestResponse(w, r, err)
+		}
+		return
+	}
+
+	if len(rows) == 0 {
+		app.badRequestResponse(w, r, errors.New("body must contain at least one movie"))
+		return
+	}
+
+	genres, err := app.models.Genres.Catalogue()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	for i := range rows {
+		if rows[i].Movie == nil {
+			continue
+		}
+
+		rows[i].Movie.Genres = genres.Normalize(rows[i].Movie.Genres)
+
+		v := validator.New()
+
+		if data.ValidateMovie(v, rows[i].Movie, genres); !v.Valid() {
+			rows[i].Fail(v.Errors)
+		}
+	}
+
+	movieImport := &data.MovieImport{
+		UserID: app.contextGetUser(r).ID,
+		Format: format,
+		Status: data.ImportStatusPending,
+		Rows:   rows,
+	}
+
+	movieImport.Tally()
+
+	err = app.models.Imports.Insert(movieImport)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/imports/%d", movieImport.ID))
+
+	if len(rows) > importSyncLimit {
+		// The job updates the import, so it only starts once the response
+		// is written.
+		err = app.writeResponse(w, r, http.StatusAccepted, envelope{"import": movieImport}, headers)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+
+		app.background(func() {
+			err := app.runMovieImport(movieImport)
+			if err != nil {
+				app.logger.PrintError(err, map[string]string{
+					"import": strconv.FormatInt(movieImport.ID, 10),
+				})
+			}
+		})
+		return
+	}
+
+	err = app.runMovieImport(movieImport)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeResponse(w, r, http.StatusCreated, envelope{"import": movieImport}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showImportHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	movieImport, err := app.models.Imports.Get(id, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeResponse(w, r, http.StatusOK, envelope{"import": movieImport}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// runMovieImport inserts the valid rows of the import in batches, saving the
+// report after each one. A row that fails to insert is reported on its own,
+// and a batch that fails to commit has all its rows reported as failed, the
+// remaining batches are still attempted.
+func (app *application) runMovieImport(movieImport *data.MovieImport) error {
+	movieImport.Status = data.ImportStatusRunning
+
+	err := app.models.Imports.Update(movieImport)
+	if err != nil {
+		return err
+	}
+
+	var pending []int
+
+	for i := range movieImport.Rows {
+		if movieImport.Rows[i].Movie != nil {
+			pending = append(pending, i)
+		}
+	}
+
+	for start := 0; start < len(pending); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+
+		batch := pending[start:end]
+
+		movies := make([]*data.Movie, len(batch))
+		for j, i := range batch {
+			movies[j] = movieImport.Rows[i].Movie
+		}
+
+		errs, err := app.models.Movies.InsertBatch(movies)
+		if err != nil {
+			app.logger.PrintError(err, map[string]string{
+				"import": strconv.FormatInt(movieImport.ID, 10),
+			})
+		}
+
+		for j, i := range batch {
+			row := &movieImport.Rows[i]
+
+			if err != nil || errs[j] != nil {
+				row.Fail(map[string]string{"movie": "could not be saved"})
+				continue
+			}
+
+			row.Status = data.ImportRowCreated
+			row.MovieID = row.Movie.ID
+			row.Movie = nil
+		}
+
+		if end < len(pending) {
+			movieImport.Tally()
+
+			err = app.models.Imports.Update(movieImport)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	movieImport.Status = data.ImportStatusCompleted
+	movieImport.Tally()
+
+	return app.models.Imports.Update(movieImport)
+}
+
+// failStaleImports periodically marks the imports left pending or running
+// by an interrupted job as failed, until the context is done. It first runs
+// on startup, for the jobs of the previous process.
+func (app *application) failStaleImports(ctx context.Context) {
+	ticker := time.NewTicker(importStaleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		failed, err := app.models.Imports.FailStale(importStaleAfter)
+		if err != nil {
+			app.logger.PrintError(err, nil)
+		}
+
+		if failed > 0 {
+			app.logger.PrintInfo("failed interrupted movie imports", map[string]string{
+				"count": strconv.FormatInt(failed, 10),
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// parseCSVImport reads movies from a CSV document whose first line is a
+// header naming the columns. Genres are separated by a "|" and the runtime
+// is given in minutes, optionally followed by " mins".
+func parseCSVImport(body io.Reader) ([]data.ImportRow, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("body must not be empty")
+		}
+
+		return nil, csvImportError(err)
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if !validator.PermittedValue(name, importCSVColumns...) {
+			return nil, fmt.Errorf("body contains unknown column %q", name)
+		}
+
+		if _, exists := columns[name]; exists {
+			return nil, fmt.Errorf("body contains duplicate column %q", name)
+		}
+
+		columns[name] = i
+	}
+
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New(`body must contain a "title" column`)
+	}
+
+	rows := []data.ImportRow{}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, csvImportError(err)
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		if err != nil {
+			rows = append(rows, data.ImportRow{
+				Line:   line,
+				Status: data.ImportRowFailed,
+				Errors: map[string]string{"row": fmt.Sprintf("must contain %d columns", len(header))},
+			})
+			continue
+		}
+
+		rows = append(rows, parseCSVImportRecord(line, record, columns))
+	}
+
+	return rows, nil
+}
+
+func parseCSVImportRecord(line int, record []string, columns map[string]int) data.ImportRow {
+	row := data.ImportRow{Line: line, Movie: &data.Movie{}}
+
+	field := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+
+		return ""
+	}
+
+	errs := map[string]string{}
+
+	row.Movie.Title = field("title")
+
+	if year := field("year"); year != "" {
+		i, err := strconv.ParseInt(year, 10, 32)
+		if err != nil {
+			errs["year"] = "must be an integer"
+		}
+
+		row.Movie.Year = int32(i)
+	}
+
+	if runtime := field("runtime"); runtime != "" {
+		i, err := strconv.ParseInt(strings.TrimSuffix(runtime, " mins"), 10, 32)
+		if err != nil {
+			errs["runtime"] = data.ErrInvalidRuntimeFormat.Error()
+		}
+
+		row.Movie.Runtime = data.Runtime(i)
+	}
+
+	if genres := field("genres"); genres != "" {
+		for _, genre := range strings.Split(genres, "|") {
+			row.Movie.Genres = append(row.Movie.Genres, strings.TrimSpace(genre))
+		}
+	}
+
+	if len(errs) > 0 {
+		row.Fail(errs)
+	}
+
+	return row
+}
+
+func csvImportError(err error) error {
+	var parseError *csv.ParseError
+	var maxBytesError *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesError):
+		return err
+	case errors.As(err, &parseError):
+		return fmt.Errorf("body contains badly-formed CSV (at line %d)", parseError.Line)
+	default:
+		return err
+	}
+}
+
+// parseNDJSONImport reads one movie per line, each encoded as the same JSON
+// object accepted by createMoviesHandler. Blank lines are skipped.
+func parseNDJSONImport(body io.Reader) ([]data.ImportRow, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1_048_576)
+
+	rows := []data.ImportRow{}
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var input struct {
+			Title   string       `json:"title"`
+			Genres  []string     `json:"genres"`
+			Year    int32        `json:"year"`
+			Runtime data.Runtime `json:"runtime"`
+		}
+
+		dec := json.NewDecoder(strings.NewReader(text))
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&input)
+		if err == nil && dec.More() {
+			err = errors.New("line must only contain a single JSON value")
+		}
+
+		if err != nil {
+			rows = append(rows, data.ImportRow{
+				Line:   line,
+				Status: data.ImportRowFailed,
+				Errors: map[string]string{"row": err.Error()},
+			})
+			continue
+		}
+
+		rows = append(rows, data.ImportRow{
+			Line: line,
+			Movie: &data.Movie{
+				Title:   input.Title,
+				Year:    input.Year,
+				Runtime: input.Runtime,
+				Genres:  input.Genres,
+			},
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, errors.New("body contains a line longer than 1048576 bytes")
+		}
+
+		return nil, err
+	}
+
+	return rows, nil
+}
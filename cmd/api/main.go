@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"expvar"
 	"flag"
+	"fmt"
 	"os"
 	"runtime"
 	"strings"
@@ -14,6 +15,8 @@ import (
 	"github.com/brGuirra/greenlight/internal/data"
 	"github.com/brGuirra/greenlight/internal/jsonlog"
 	"github.com/brGuirra/greenlight/internal/mailer"
+	"github.com/brGuirra/greenlight/internal/storage"
+	"github.com/brGuirra/greenlight/internal/webhook"
 	_ "github.com/lib/pq"
 )
 
@@ -43,14 +46,54 @@ type config struct {
 	cors struct {
 		trustedOrigins []string
 	}
+	imports struct {
+		maxBytes int64
+	}
+	posters struct {
+		maxBytes int64
+	}
+	storage struct {
+		dir     string
+		baseURL string
+	}
+	preconditions struct {
+		required bool
+	}
+	problems struct {
+		enabled bool
+	}
+	docs struct {
+		enabled bool
+	}
+	idempotency struct {
+		ttl time.Duration
+	}
+	events struct {
+		logSize int
+	}
+	webhooks struct {
+		maxAttempts int
+		interval    time.Duration
+		timeout     time.Duration
+		// allowPrivate lets deliveries reach loopback and private
+		// addresses, which are refused otherwise.
+		allowPrivate bool
+	}
+	trash struct {
+		retention time.Duration
+		interval  time.Duration
+	}
 }
 
 type application struct {
-	logger *jsonlog.Logger
-	config config
-	models data.Models
-	mailer mailer.Mailer
-	wg     sync.WaitGroup
+	logger   *jsonlog.Logger
+	config   config
+	models   data.Models
+	mailer   mailer.Mailer
+	storage  storage.Storage
+	events   *eventBroker
+	webhooks webhook.Sender
+	wg       sync.WaitGroup
 }
 
 func main() {
@@ -80,10 +123,47 @@ func main() {
 		return nil
 	})
 
+	flag.Int64Var(&cfg.imports.maxBytes, "import-max-bytes", 10_485_760, "Maximum size in bytes of a movie import")
+
+	flag.Int64Var(&cfg.posters.maxBytes, "poster-max-bytes", 10_485_760, "Maximum size in bytes of a movie poster")
+
+	flag.StringVar(&cfg.storage.dir, "storage-dir", "./uploads", "Directory where uploaded files are stored")
+	flag.StringVar(&cfg.storage.baseURL, "storage-base-url", "http://localhost:4000/media", "Base URL the uploaded files are served from")
+
+	flag.BoolVar(&cfg.preconditions.required, "require-if-match", false, "Require an If-Match header on movie updates and deletes")
+
+	flag.DurationVar(&cfg.idempotency.ttl, "idempotency-key-ttl", 24*time.Hour, "How long responses to requests with an Idempotency-Key are kept for replay")
+
+	flag.IntVar(&cfg.events.logSize, "movie-events-log-size", 1000, "Number of movie events kept for clients resuming an event stream")
+
+	flag.IntVar(&cfg.webhooks.maxAttempts, "webhook-max-attempts", 10, "Attempts at delivering a webhook event before giving up")
+	flag.DurationVar(&cfg.webhooks.interval, "webhook-poll-interval", 5*time.Second, "Interval between checks for webhook deliveries to send")
+	flag.DurationVar(&cfg.webhooks.timeout, "webhook-timeout", 10*time.Second, "Timeout of a webhook delivery request")
+	flag.BoolVar(&cfg.webhooks.allowPrivate, "webhook-allow-private-addresses", false, "Allow webhook deliveries to loopback and private addresses, for local development only")
+
+	flag.BoolVar(&cfg.docs.enabled, "docs-enabled", true, "Serve the API documentation at /v1/docs")
+
+	flag.BoolVar(&cfg.problems.enabled, "problem-details", false, "Send every error as an RFC 7807 problem details document")
+
+	flag.DurationVar(&cfg.trash.retention, "trash-retention", 30*24*time.Hour, "How long deleted movies are kept before being purged")
+	flag.DurationVar(&cfg.trash.interval, "trash-purge-interval", time.Hour, "Interval between purges of deleted movies")
+
 	flag.Parse()
 
 	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
 
+	// Tickers panic on a non-positive interval, and a zero timeout would fail
+	// every webhook delivery.
+	for name, interval := range map[string]time.Duration{
+		"trash-purge-interval":  cfg.trash.interval,
+		"webhook-poll-interval": cfg.webhooks.interval,
+		"webhook-timeout":       cfg.webhooks.timeout,
+	} {
+		if interval <= 0 {
+			logger.PrintFatal(fmt.Errorf("-%s must be greater than zero", name), nil)
+		}
+	}
+
 	db, err := openDB(cfg)
 	if err != nil {
 		logger.PrintFatal(err, nil)
@@ -107,11 +187,22 @@ func main() {
 		return time.Now().Unix()
 	}))
 
+	store, err := storage.NewLocal(cfg.storage.dir, cfg.storage.baseURL)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
 	app := &application{
-		config: cfg,
-		logger: logger,
-		models: data.NewModels(db),
-		mailer: mailer.New(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender),
+		config:  cfg,
+		logger:  logger,
+		models:  data.NewModels(db),
+		mailer:  mailer.New(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender),
+		storage: store,
+		events:  newEventBroker(),
+		webhooks: webhook.Sender{
+			Client:    webhook.NewClient(cfg.webhooks.timeout, cfg.webhooks.allowPrivate),
+			UserAgent: "greenlight-webhooks/" + version,
+		},
 	}
 
 	err = app.serve()
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"expvar"
 	"fmt"
+	"math"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,6 +21,33 @@ import (
 	"golang.org/x/time/rate"
 )
 
+var requestIDRX = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requestID tags every request with an id, reusing the X-Request-Id header
+// sent by the client or a proxy when it is well-formed, and echoes it in the
+// response so that errors can be matched with the server logs.
+func (app *application) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-Id")
+
+		if !requestIDRX.MatchString(id) {
+			b := make([]byte, 16)
+
+			_, err := rand.Read(b)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+
+			id = hex.EncodeToString(b)
+		}
+
+		w.Header().Set("X-Request-Id", id)
+
+		next.ServeHTTP(w, app.contextSetRequestID(r, id))
+	})
+}
+
 func (app *application) recoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
@@ -71,6 +102,13 @@ func (app *application) rateLimit(next http.Handler) http.Handler {
 
 			if !clients[ip].limiter.Allow() {
 				mu.Unlock()
+
+				// A token is added to the bucket every 1/rps seconds.
+				if app.config.limiter.rps > 0 {
+					retryAfter := math.Ceil(1 / app.config.limiter.rps)
+					w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter)))
+				}
+
 				app.rateLimitExceededResponse(w, r)
 				return
 			}
@@ -154,6 +192,8 @@ func (app *application) requireActivateUser(next http.HandlerFunc) http.HandlerF
 }
 
 func (app *application) requirePermission(code string, next http.HandlerFunc) http.HandlerFunc {
+	next = app.idempotency(app.idempotencyMaxBytes(), next)
+
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		user := app.contextGetUser(r)
 
@@ -189,7 +229,7 @@ func (app *application) enableCORS(next http.Handler) http.Handler {
 
 					if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
 						w.Header().Set("Access-Control-Allow-Methods", "OPTIONS, PUT, PATCH, DELETE")
-						w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+						w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Idempotency-Key, Last-Event-ID")
 
 						w.WriteHeader(http.StatusNoContent)
 						return
@@ -235,6 +275,16 @@ func (mw *metricsResponseWriter) Write(b []byte) (int, error) {
 	return mw.wrapped.Write(b)
 }
 
+// Flush sends the buffered data to the client, when the wrapped writer can,
+// so that streaming handlers work behind the metrics middleware.
+func (mw *metricsResponseWriter) Flush() {
+	mw.headerWritten = true
+
+	if flusher, ok := mw.wrapped.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 func (mw *metricsResponseWriter) Unwrap() http.ResponseWriter {
 	return mw.wrapped
 }
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
 
 	"github.com/brGuirra/greenlight/internal/data"
+	"github.com/brGuirra/greenlight/internal/patch"
 	"github.com/brGuirra/greenlight/internal/validator"
 )
 
@@ -23,20 +27,41 @@ func (app *application) createMoviesHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	genres, err := app.models.Genres.Catalogue()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	movie := &data.Movie{
 		Title:   input.Title,
 		Year:    input.Year,
 		Runtime: input.Runtime,
-		Genres:  input.Genres,
+		Genres:  genres.Normalize(input.Genres),
 	}
 
 	v := validator.New()
 
-	if data.ValidateMovie(v, movie); !v.Valid() {
+	force := app.readBool(r.URL.Query(), "force", false, v)
+
+	if data.ValidateMovie(v, movie, genres); !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
+	if !force {
+		candidates, err := app.models.Movies.FindDuplicates(movie.Title, movie.Year)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if len(candidates) > 0 {
+			app.duplicateMovieResponse(w, r, candidates)
+			return
+		}
+	}
+
 	err = app.models.Movies.Insert(movie)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -46,7 +71,7 @@ func (app *application) createMoviesHandler(w http.ResponseWriter, r *http.Reque
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/movies/%d", movie.ID))
 
-	err = app.writeJSON(w, http.StatusCreated, envelope{"movie": movie}, headers)
+	err = app.writeResponse(w, r, http.StatusCreated, envelope{"movie": movie}, headers)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -59,18 +84,71 @@ func (app *application) showMovieHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	movie, err := app.models.Movies.Get(id)
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	// Credits are embedded by default, unless the response is trimmed to
+	// a sparse fieldset.
+	defaultInclude := []string{"credits"}
+	if qs.Has("fields") {
+		defaultInclude = []string{}
+	}
+
+	fields, include := app.readMovieFieldset(qs, defaultInclude, v)
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	movie, err := app.models.Movies.GetFields(id, fields)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
-			app.notFoundResponse(w, r)
+			app.movieRedirectResponse(w, r, id)
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"movie": movie}, nil)
+	languages := app.readLanguages(w, r)
+
+	w.Header().Set("Accept-Patch", movieAcceptPatch)
+
+	setMovieValidators(w, &movie)
+
+	if notModified(r, movieETag(&movie), movie.UpdatedAt) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if validator.PermittedValue("credits", include...) {
+		movie.Credits, err = app.models.Credits.GetForMovie(movie.ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	movie.Localize(languages)
+
+	if len(fields) == 0 {
+		err = app.writeResponse(w, r, http.StatusOK, envelope{"movie": movie}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	trimmed, err := selectFields(movie, append(fields, include...))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeResponse(w, r, http.StatusOK, envelope{"movie": trimmed}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -95,43 +173,81 @@ func (app *application) updateMovieHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	var input struct {
-		Title   *string       `json:"title"`
-		Year    *int32        `json:"year"`
-		Runtime *data.Runtime `json:"runtime"`
-		Genres  []string      `json:"genres"`
+	if !app.checkIfMatch(w, r, movieETag(&movie)) {
+		return
 	}
 
-	err = app.readJSON(w, r, &input)
+	genres, err := app.models.Genres.Catalogue()
 	if err != nil {
-		app.badRequestResponse(w, r, err)
+		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	if input.Title != nil {
-		movie.Title = *input.Title
-	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
 
-	if input.Year != nil {
-		movie.Year = *input.Year
-	}
+	switch mediaType {
+	case mergePatchMediaType, jsonPatchMediaType:
+		var body json.RawMessage
 
-	if input.Runtime != nil {
-		movie.Runtime = *input.Runtime
-	}
+		err = app.readJSON(w, r, &body)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
 
-	if input.Genres != nil {
-		movie.Genres = input.Genres
+		err = applyMoviePatch(&movie, mediaType, body)
+		if err != nil {
+			switch {
+			case errors.Is(err, patch.ErrMalformedPatch):
+				app.badRequestResponse(w, r, err)
+			case errors.Is(err, patch.ErrTestFailed):
+				app.patchTestFailedResponse(w, r, err)
+			default:
+				app.unprocessablePatchResponse(w, r, err)
+			}
+			return
+		}
+
+		movie.Genres = genres.Normalize(movie.Genres)
+	default:
+		var input struct {
+			Title   *string       `json:"title"`
+			Year    *int32        `json:"year"`
+			Runtime *data.Runtime `json:"runtime"`
+			Genres  []string      `json:"genres"`
+		}
+
+		err = app.readJSON(w, r, &input)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		if input.Title != nil {
+			movie.Title = *input.Title
+		}
+
+		if input.Year != nil {
+			movie.Year = *input.Year
+		}
+
+		if input.Runtime != nil {
+			movie.Runtime = *input.Runtime
+		}
+
+		if input.Genres != nil {
+			movie.Genres = genres.Normalize(input.Genres)
+		}
 	}
 
 	v := validator.New()
 
-	if data.ValidateMovie(v, &movie); !v.Valid() {
+	if data.ValidateMovie(v, &movie, genres); !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	err = app.models.Movies.Update(&movie)
+	err = app.models.Movies.Update(&movie, app.contextGetUser(r).ID)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrEditConflict):
@@ -142,7 +258,9 @@ func (app *application) updateMovieHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"movie": movie}, nil)
+	setMovieValidators(w, &movie)
+
+	err = app.writeResponse(w, r, http.StatusOK, envelope{"movie": movie}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -155,11 +273,35 @@ func (app *application) deleteMovieHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	err = app.models.Movies.Delete(id)
+	// Without an If-Match header the movie is deleted whatever its version.
+	var version int32
+
+	if len(r.Header.Values("If-Match")) > 0 || app.config.preconditions.required {
+		movie, err := app.models.Movies.Get(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		if !app.checkIfMatch(w, r, movieETag(&movie)) {
+			return
+		}
+
+		version = movie.Version
+	}
+
+	err = app.models.Movies.Delete(id, version, app.contextGetUser(r).ID)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
@@ -171,8 +313,7 @@ func (app *application) deleteMovieHandler(w http.ResponseWriter, r *http.Reques
 
 func (app *application) listMoviesHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		Title  string
-		Genres []string
+		data.MovieQuery
 		data.Filters
 	}
 
@@ -180,8 +321,11 @@ func (app *application) listMoviesHandler(w http.ResponseWriter, r *http.Request
 
 	qs := r.URL.Query()
 
-	input.Title = app.readString(qs, "title", "")
-	input.Genres = app.readCSV(qs, "genres", []string{})
+	input.MovieQuery = app.readMovieQuery(r, qs, v)
+
+	var include []string
+	input.Fields, include = app.readMovieFieldset(qs, []string{}, v)
+
 	input.Page = app.readInt(qs, "page", 1, v)
 	input.PageSize = app.readInt(qs, "pageSize", 20, v)
 	input.Sort = app.readString(qs, "sort", "id")
@@ -192,13 +336,228 @@ func (app *application) listMoviesHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	movies, metadata, err := app.models.Movies.GetAll(input.Title, input.Genres, input.Filters)
+	err := app.normalizeMovieQuery(&input.MovieQuery)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	movies, metadata, err := app.models.Movies.GetAll(input.MovieQuery, input.Filters)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"metadata": metadata, "movies": movies}, nil)
+	languages := app.readLanguages(w, r)
+
+	for i := range movies {
+		movies[i].Localize(languages)
+	}
+
+	if validator.PermittedValue("credits", include...) && len(movies) > 0 {
+		ids := make([]int64, len(movies))
+
+		for i := range movies {
+			ids[i] = movies[i].ID
+		}
+
+		credits, err := app.models.Credits.GetForMovies(ids)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		for i := range movies {
+			movies[i].Credits = credits[movies[i].ID]
+		}
+	}
+
+	if len(input.Fields) == 0 {
+		err = app.writeResponse(w, r, http.StatusOK, envelope{"metadata": metadata, "movies": movies}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	keys := append(input.Fields, include...)
+	trimmed := make([]map[string]json.RawMessage, len(movies))
+
+	for i := range movies {
+		trimmed[i], err = selectFields(movies[i], keys)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	err = app.writeResponse(w, r, http.StatusOK, envelope{"metadata": metadata, "movies": trimmed}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readMovieQuery reads and validates the search criteria shared by the
+// movie list and export endpoints.
+func (app *application) readMovieQuery(r *http.Request, qs url.Values, v *validator.Validator) data.MovieQuery {
+	var q data.MovieQuery
+
+	q.Title = app.readString(qs, "title", "")
+	q.Genres = app.readCSV(qs, "genres", []string{})
+	q.GenresAny = app.readCSV(qs, "genres_any", []string{})
+	q.GenresNot = app.readCSV(qs, "genres_not", []string{})
+	q.Director = app.readString(qs, "director", "")
+
+	if app.readBool(qs, "in_watchlist", false, v) {
+		q.InWatchlistOf = app.contextGetUser(r).ID
+	}
+
+	data.ValidateGenresFilters(v, q.Genres, q.GenresAny, q.GenresNot)
+
+	return q
+}
+
+// normalizeMovieQuery maps the genre criteria to their catalogue slugs.
+func (app *application) normalizeMovieQuery(q *data.MovieQuery) error {
+	genres, err := app.models.Genres.Catalogue()
+	if err != nil {
+		return err
+	}
+
+	q.Genres = genres.Normalize(q.Genres)
+	q.GenresAny = genres.Normalize(q.GenresAny)
+	q.GenresNot = genres.Normalize(q.GenresNot)
+
+	return nil
+}
+
+func (app *application) listTrashedMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		data.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Page = app.readInt(qs, "page", 1, v)
+	input.PageSize = app.readInt(qs, "pageSize", 20, v)
+	input.Sort = app.readString(qs, "sort", "id")
+	input.SortSafeList = data.MoviesSortSafeList
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	movies, metadata, err := app.models.Movies.GetAll(data.MovieQuery{Deleted: true}, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeResponse(w, r, http.StatusOK, envelope{"metadata": metadata, "movies": movies}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) restoreMovieHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Movies.Restore(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	movie, err := app.models.Movies.Get(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeResponse(w, r, http.StatusOK, envelope{"movie": movie}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// movieRedirectResponse points clients to the movie an id was merged into,
+// falling back to a not found response for ids that were never merged.
+func (app *application) movieRedirectResponse(w http.ResponseWriter, r *http.Request, id int64) {
+	movieID, err := app.models.Movies.ResolveRedirect(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/movies/%d", movieID))
+
+	err = app.writeResponse(w, r, http.StatusMovedPermanently, envelope{"movieId": movieID}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// mergeMovieHandler folds the movie into the one given as target, which is
+// returned in its merged state.
+func (app *application) mergeMovieHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		TargetID int64 `json:"targetId"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	v.Check(input.TargetID > 0, "targetId", "must be provided")
+	v.Check(input.TargetID != id, "targetId", "must be a different movie")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	movie, poster, err := app.models.Movies.Merge(id, input.TargetID, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.deletePosterFiles(poster)
+
+	err = app.writeResponse(w, r, http.StatusOK, envelope{"movie": movie}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -0,0 +1,836 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brGuirra/greenlight/internal/data"
+	"github.com/brGuirra/greenlight/internal/openapi"
+	"github.com/brGuirra/greenlight/internal/patch"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// apiOperation describes a route registered in routes for the OpenAPI
+// document. The request body and response are given as Go values, whose
+// schemas are derived from their types.
+type apiOperation struct {
+	method     string
+	path       string
+	id         string
+	summary    string
+	tag        string
+	permission string
+	// description adds to the generated description of the operation.
+	description string
+	// query lists the names of the query string parameters, defined in
+	// apiParameters, and sort the values of the "sort" one, if any.
+	query []string
+	sort  []string
+	// headers lists the names of the request header parameters, defined in
+	// the components of the document.
+	headers []string
+	// body holds a value of the request body type by media type.
+	body map[string]any
+	// status lists the success statuses, all sharing the response, which is
+	// an envelope, a value whose type is the body, or nil for no content.
+	status   []int
+	response any
+	// produces lists the media types of responses that are not JSON.
+	produces []string
+	// errors lists the error statuses beyond those every operation of its
+	// kind can fail with, see operationErrors.
+	errors []int
+	// secretResponse marks the operations whose responses hold secrets,
+	// which are not stored for Idempotency-Key retries.
+	secretResponse bool
+}
+
+// binaryFile stands for the content of a file in request and response
+// bodies.
+type binaryFile struct{}
+
+func jsonBody(v any) map[string]any {
+	return map[string]any{"application/json": v}
+}
+
+type movieInput struct {
+	Title   string       `json:"title"`
+	Genres  []string     `json:"genres"`
+	Year    int32        `json:"year"`
+	Runtime data.Runtime `json:"runtime"`
+}
+
+type movieUpdateInput struct {
+	Title   *string       `json:"title"`
+	Year    *int32        `json:"year"`
+	Runtime *data.Runtime `json:"runtime"`
+	Genres  []string      `json:"genres,omitempty"`
+}
+
+// apiParameters holds the query string parameters shared by operations.
+var apiParameters = map[string]*openapi.Parameter{
+	"page": {
+		Description: "Page number, from 1 to 10,000,000.",
+		Schema:      &openapi.Schema{Type: "integer", Default: 1},
+	},
+	"pageSize": {
+		Description: "Records per page, from 1 to 100.",
+		Schema:      &openapi.Schema{Type: "integer", Default: 20},
+	},
+	"title": {
+		Description: "Full-text search on the movie titles, in every language.",
+		Schema:      &openapi.Schema{Type: "string"},
+	},
+	"genres": {
+		Description: "Comma-separated genres the movies must all have.",
+		Schema:      &openapi.Schema{Type: "string"},
+	},
+	"genres_any": {
+		Description: "Comma-separated genres the movies must have at least one of.",
+		Schema:      &openapi.Schema{Type: "string"},
+	},
+	"genres_not": {
+		Description: "Comma-separated genres the movies must not have.",
+		Schema:      &openapi.Schema{Type: "string"},
+	},
+	"director": {
+		Description: "Name of a director of the movies.",
+		Schema:      &openapi.Schema{Type: "string"},
+	},
+	"in_watchlist": {
+		Description: "Only list movies in the watchlist of the authenticated user.",
+		Schema:      &openapi.Schema{Type: "boolean", Default: false},
+	},
+	"fields": {
+		Description: "Comma-separated fields the movies are trimmed to, the id is always sent. One of " + strings.Join(data.MoviesFieldsSafeList, ", ") + ".",
+		Schema:      &openapi.Schema{Type: "string"},
+	},
+	"include": {
+		Description: "Comma-separated related data embedded in the movies. One of " + strings.Join(data.MoviesIncludeSafeList, ", ") + ".",
+		Schema:      &openapi.Schema{Type: "string"},
+	},
+	"force": {
+		Description: "Create the movie even when similar ones already exist.",
+		Schema:      &openapi.Schema{Type: "boolean", Default: false},
+	},
+	"format": {
+		Description: "Format of the export.",
+		Schema:      &openapi.Schema{Type: "string", Enum: []string{data.ImportFormatNDJSON, data.ImportFormatCSV}, Default: data.ImportFormatNDJSON},
+	},
+	"name": {
+		Description: "Full-text search on the names of people.",
+		Schema:      &openapi.Schema{Type: "string"},
+	},
+	"token": {
+		Description: "Share token of an unlisted collection.",
+		Schema:      &openapi.Schema{Type: "string"},
+	},
+	"status": {
+		Description: "Only list the deliveries with this status.",
+		Schema:      &openapi.Schema{Type: "string", Enum: data.WebhookDeliveryStatuses},
+	},
+}
+
+var (
+	movieListQuery = []string{"page", "pageSize", "title", "genres", "genres_any", "genres_not", "director", "in_watchlist", "fields", "include"}
+	pageQuery      = []string{"page", "pageSize"}
+)
+
+// apiOperations lists every route of the API. Tests check it against the
+// routes registered in routes.go, so a route cannot be added without an
+// entry here.
+var apiOperations = []apiOperation{
+	{
+		method: http.MethodGet, path: "/v1/healthcheck", id: "healthcheck", summary: "Show the application status", tag: "system",
+		status: []int{http.StatusOK}, response: envelope{"status": "", "systemInfo": map[string]string{}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/metrics", id: "showMetrics", summary: "Show the application metrics", tag: "system",
+		status: []int{http.StatusOK}, response: map[string]any{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/openapi.json", id: "showOpenAPI", summary: "Show this OpenAPI document", tag: "system",
+		status: []int{http.StatusOK}, response: map[string]any{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/docs", id: "showDocs", summary: "Browse the API documentation", tag: "system",
+		status: []int{http.StatusOK}, response: binaryFile{}, produces: []string{"text/html"},
+	},
+	{
+		method: http.MethodGet, path: "/media/*filepath", id: "showMedia", summary: "Download an uploaded file", tag: "system",
+		status: []int{http.StatusOK}, response: binaryFile{}, produces: []string{"image/jpeg", "image/png", "image/gif"},
+	},
+
+	{
+		method: http.MethodGet, path: "/v1/movies", id: "listMovies", summary: "List movies", tag: "movies", permission: "movies:read",
+		query: movieListQuery, sort: data.MoviesSortSafeList,
+		status: []int{http.StatusOK}, response: envelope{"metadata": data.Metadata{}, "movies": []data.Movie{}},
+	},
+	{
+		method: http.MethodPost, path: "/v1/movies", id: "createMovie", summary: "Create a movie", tag: "movies", permission: "movies:write",
+		query: []string{"force"}, body: jsonBody(movieInput{}),
+		status: []int{http.StatusCreated}, response: envelope{"movie": data.Movie{}},
+		errors: []int{http.StatusConflict},
+	},
+	{
+		method: http.MethodGet, path: "/v1/movies/:id", id: "showMovie", summary: "Show a movie", tag: "movies", permission: "movies:read",
+		query:  []string{"fields", "include"},
+		status: []int{http.StatusOK}, response: envelope{"movie": data.Movie{}},
+		errors: []int{http.StatusMovedPermanently},
+	},
+	{
+		method: http.MethodPatch, path: "/v1/movies/:id", id: "updateMovie", summary: "Update a movie", tag: "movies", permission: "movies:write",
+		body: map[string]any{
+			"application/json":  movieUpdateInput{},
+			mergePatchMediaType: movieUpdateInput{},
+			jsonPatchMediaType:  []patch.Operation{},
+		},
+		status: []int{http.StatusOK}, response: envelope{"movie": data.Movie{}},
+		errors: []int{http.StatusConflict, http.StatusPreconditionFailed, http.StatusPreconditionRequired},
+	},
+	{
+		method: http.MethodDelete, path: "/v1/movies/:id", id: "deleteMovie", summary: "Move a movie to the trash", tag: "movies", permission: "movies:write",
+		status: []int{http.StatusNoContent},
+		errors: []int{http.StatusPreconditionFailed, http.StatusPreconditionRequired},
+	},
+	{
+		method: http.MethodPost, path: "/v1/movies/:id/restore", id: "restoreMovie", summary: "Restore a movie from the trash", tag: "movies", permission: "movies:write",
+		status: []int{http.StatusOK}, response: envelope{"movie": data.Movie{}},
+	},
+	{
+		method: http.MethodPost, path: "/v1/movies/:id/merge", id: "mergeMovie", summary: "Merge a duplicate movie into another", tag: "movies", permission: "movies:merge",
+		body: jsonBody(struct {
+			TargetID int64 `json:"targetId"`
+		}{}),
+		status: []int{http.StatusOK}, response: envelope{"movie": data.Movie{}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/movies/events", id: "streamMovieEvents", summary: "Stream the movie changes as Server-Sent Events", tag: "movies", permission: "movies:read",
+		description: "Event IDs follow the commit order of the changes, reconnect with the last one received in the Last-Event-ID header to resume the stream.",
+		headers:     []string{"Last-Event-ID"},
+		status:      []int{http.StatusOK}, response: binaryFile{}, produces: []string{"text/event-stream"},
+		errors: []int{http.StatusUnprocessableEntity},
+	},
+	{
+		method: http.MethodGet, path: "/v1/trash/movies", id: "listTrashedMovies", summary: "List movies in the trash", tag: "movies", permission: "movies:write",
+		query: pageQuery, sort: data.MoviesSortSafeList,
+		status: []int{http.StatusOK}, response: envelope{"metadata": data.Metadata{}, "movies": []data.Movie{}},
+	},
+	{
+		method: http.MethodPost, path: "/v1/movies/batch", id: "batchMovies", summary: "Create, update and delete movies in a batch", tag: "movies", permission: "movies:write",
+		body: jsonBody(struct {
+			Atomic     bool `json:"atomic,omitempty"`
+			Operations []struct {
+				Op      string        `json:"op"`
+				ID      int64         `json:"id,omitempty"`
+				Version int32         `json:"version,omitempty"`
+				Title   *string       `json:"title"`
+				Year    *int32        `json:"year"`
+				Runtime *data.Runtime `json:"runtime"`
+				Genres  []string      `json:"genres,omitempty"`
+			} `json:"operations"`
+		}{}),
+		status: []int{http.StatusOK}, response: envelope{"results": []batchResult{}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/movies/export", id: "exportMovies", summary: "Export movies", tag: "movies", permission: "movies:read",
+		query: []string{"format", "title", "genres", "genres_any", "genres_not", "director", "in_watchlist"}, sort: data.MoviesSortSafeList,
+		status: []int{http.StatusOK}, response: binaryFile{}, produces: []string{"application/x-ndjson", "text/csv"},
+	},
+	{
+		method: http.MethodPost, path: "/v1/movies/import", id: "importMovies", summary: "Import movies", tag: "movies", permission: "movies:write",
+		description: "Imports of more than 100 movies run in the background and answer 202, poll the Location header for the report.",
+		body:        map[string]any{"application/x-ndjson": binaryFile{}, "text/csv": binaryFile{}},
+		status:      []int{http.StatusCreated, http.StatusAccepted}, response: envelope{"import": data.MovieImport{}},
+		errors: []int{http.StatusUnsupportedMediaType},
+	},
+	{
+		method: http.MethodGet, path: "/v1/imports/:id", id: "showImport", summary: "Show a movie import", tag: "movies", permission: "movies:write",
+		status: []int{http.StatusOK}, response: envelope{"import": data.MovieImport{}},
+	},
+	{
+		method: http.MethodPut, path: "/v1/movies/:id/poster", id: "uploadMoviePoster", summary: "Upload the poster of a movie", tag: "movies", permission: "movies:write",
+		body: map[string]any{"multipart/form-data": struct {
+			Poster binaryFile `json:"poster"`
+		}{}},
+		status: []int{http.StatusOK}, response: envelope{"movie": data.Movie{}},
+		errors: []int{http.StatusConflict, http.StatusUnsupportedMediaType},
+	},
+	{
+		method: http.MethodDelete, path: "/v1/movies/:id/poster", id: "deleteMoviePoster", summary: "Delete the poster of a movie", tag: "movies", permission: "movies:write",
+		status: []int{http.StatusNoContent},
+		errors: []int{http.StatusConflict},
+	},
+	{
+		method: http.MethodPut, path: "/v1/movies/:id/credits", id: "updateMovieCredits", summary: "Replace the credits of a movie", tag: "movies", permission: "movies:write",
+		body: jsonBody(struct {
+			Credits []data.Credit `json:"credits"`
+		}{}),
+		status: []int{http.StatusOK}, response: envelope{"movie": data.Movie{}},
+		errors: []int{http.StatusConflict, http.StatusPreconditionFailed, http.StatusPreconditionRequired},
+	},
+	{
+		method: http.MethodPut, path: "/v1/movies/:id/titles", id: "updateMovieTitles", summary: "Replace the localized titles of a movie", tag: "movies", permission: "movies:write",
+		body: jsonBody(struct {
+			Titles data.Titles `json:"titles"`
+		}{}),
+		status: []int{http.StatusOK}, response: envelope{"movie": data.Movie{}},
+		errors: []int{http.StatusConflict, http.StatusPreconditionFailed, http.StatusPreconditionRequired},
+	},
+	{
+		method: http.MethodGet, path: "/v1/movies/:id/revisions", id: "listMovieRevisions", summary: "List the revisions of a movie", tag: "movies", permission: "movies:read",
+		query: pageQuery, sort: data.RevisionsSortSafeList,
+		status: []int{http.StatusOK}, response: envelope{"metadata": data.Metadata{}, "revisions": []data.MovieRevision{}},
+	},
+	{
+		method: http.MethodPost, path: "/v1/movies/:id/revisions/:version/restore", id: "restoreMovieRevision", summary: "Restore a movie to a revision", tag: "movies", permission: "movies:write",
+		status: []int{http.StatusOK}, response: envelope{"movie": data.Movie{}},
+		errors: []int{http.StatusConflict, http.StatusPreconditionFailed, http.StatusPreconditionRequired},
+	},
+
+	{
+		method: http.MethodPut, path: "/v1/movies/:id/rating", id: "rateMovie", summary: "Rate a movie", tag: "ratings", permission: "movies:read",
+		body: jsonBody(struct {
+			Rating int `json:"rating"`
+		}{}),
+		status: []int{http.StatusOK}, response: envelope{"rating": data.Rating{}, "movie": data.Movie{}},
+	},
+	{
+		method: http.MethodDelete, path: "/v1/movies/:id/rating", id: "deleteMovieRating", summary: "Delete your rating of a movie", tag: "ratings", permission: "movies:read",
+		status: []int{http.StatusNoContent},
+	},
+	{
+		method: http.MethodGet, path: "/v1/movies/:id/reviews", id: "listReviews", summary: "List the reviews of a movie", tag: "reviews", permission: "movies:read",
+		query: pageQuery, sort: data.ReviewsSortSafeList,
+		status: []int{http.StatusOK}, response: envelope{"metadata": data.Metadata{}, "reviews": []data.Review{}},
+	},
+	{
+		method: http.MethodPost, path: "/v1/movies/:id/reviews", id: "createReview", summary: "Review a movie", tag: "reviews", permission: "movies:read",
+		body: jsonBody(struct {
+			Title string `json:"title"`
+			Body  string `json:"body"`
+		}{}),
+		status: []int{http.StatusCreated}, response: envelope{"review": data.Review{}},
+	},
+	{
+		method: http.MethodPatch, path: "/v1/movies/:id/reviews/:reviewId", id: "updateReview", summary: "Update your review of a movie", tag: "reviews", permission: "movies:read",
+		body: jsonBody(struct {
+			Title   *string `json:"title"`
+			Body    *string `json:"body"`
+			Version *int32  `json:"version"`
+		}{}),
+		status: []int{http.StatusOK}, response: envelope{"review": data.Review{}},
+		errors: []int{http.StatusConflict},
+	},
+	{
+		method: http.MethodDelete, path: "/v1/movies/:id/reviews/:reviewId", id: "deleteReview", summary: "Delete your review of a movie", tag: "reviews", permission: "movies:read",
+		status: []int{http.StatusNoContent},
+	},
+	{
+		method: http.MethodPut, path: "/v1/movies/:id/reviews/:reviewId/visibility", id: "moderateReview", summary: "Hide or show a review", tag: "reviews", permission: "reviews:moderate",
+		body: jsonBody(struct {
+			Hidden  *bool  `json:"hidden"`
+			Version *int32 `json:"version"`
+		}{}),
+		status: []int{http.StatusOK}, response: envelope{"review": data.Review{}},
+		errors: []int{http.StatusConflict},
+	},
+
+	{
+		method: http.MethodGet, path: "/v1/people", id: "listPeople", summary: "List people", tag: "people", permission: "movies:read",
+		query: []string{"name", "page", "pageSize"}, sort: data.PeopleSortSafeList,
+		status: []int{http.StatusOK}, response: envelope{"metadata": data.Metadata{}, "people": []data.Person{}},
+	},
+	{
+		method: http.MethodPost, path: "/v1/people", id: "createPerson", summary: "Create a person", tag: "people", permission: "movies:write",
+		body: jsonBody(struct {
+			Name      string `json:"name"`
+			BirthYear int32  `json:"birthYear,omitempty"`
+		}{}),
+		status: []int{http.StatusCreated}, response: envelope{"person": data.Person{}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/people/:id", id: "showPerson", summary: "Show a person", tag: "people", permission: "movies:read",
+		status: []int{http.StatusOK}, response: envelope{"person": data.Person{}},
+	},
+	{
+		method: http.MethodPatch, path: "/v1/people/:id", id: "updatePerson", summary: "Update a person", tag: "people", permission: "movies:write",
+		body: jsonBody(struct {
+			Name      *string `json:"name"`
+			BirthYear *int32  `json:"birthYear"`
+		}{}),
+		status: []int{http.StatusOK}, response: envelope{"person": data.Person{}},
+		errors: []int{http.StatusConflict},
+	},
+	{
+		method: http.MethodDelete, path: "/v1/people/:id", id: "deletePerson", summary: "Delete a person", tag: "people", permission: "movies:write",
+		status: []int{http.StatusNoContent},
+	},
+
+	{
+		method: http.MethodGet, path: "/v1/genres", id: "listGenres", summary: "List genres", tag: "genres", permission: "movies:read",
+		status: []int{http.StatusOK}, response: envelope{"genres": []data.Genre{}},
+	},
+	{
+		method: http.MethodPost, path: "/v1/genres", id: "createGenre", summary: "Create a genre", tag: "genres", permission: "genres:write",
+		body: jsonBody(struct {
+			Slug    string   `json:"slug"`
+			Name    string   `json:"name"`
+			Aliases []string `json:"aliases,omitempty"`
+		}{}),
+		status: []int{http.StatusCreated}, response: envelope{"genre": data.Genre{}},
+	},
+	{
+		method: http.MethodPatch, path: "/v1/genres/:id", id: "updateGenre", summary: "Update a genre", tag: "genres", permission: "genres:write",
+		body: jsonBody(struct {
+			Slug    *string  `json:"slug"`
+			Name    *string  `json:"name"`
+			Aliases []string `json:"aliases,omitempty"`
+		}{}),
+		status: []int{http.StatusOK}, response: envelope{"genre": data.Genre{}},
+		errors: []int{http.StatusConflict},
+	},
+	{
+		method: http.MethodDelete, path: "/v1/genres/:id", id: "deleteGenre", summary: "Delete a genre", tag: "genres", permission: "genres:write",
+		status: []int{http.StatusNoContent},
+		errors: []int{http.StatusConflict},
+	},
+
+	{
+		method: http.MethodGet, path: "/v1/collections", id: "listPublicCollections", summary: "List public collections", tag: "collections",
+		query: pageQuery, sort: data.CollectionsSortSafeList,
+		status: []int{http.StatusOK}, response: envelope{"metadata": data.Metadata{}, "collections": []data.Collection{}},
+	},
+	{
+		method: http.MethodPost, path: "/v1/collections", id: "createCollection", summary: "Create a collection", tag: "collections", permission: "movies:read",
+		body: jsonBody(struct {
+			Name        string `json:"name"`
+			Description string `json:"description,omitempty"`
+			Visibility  string `json:"visibility,omitempty"`
+		}{}),
+		status: []int{http.StatusCreated}, response: envelope{"collection": data.Collection{}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/collections/:id", id: "showCollection", summary: "Show a collection", tag: "collections",
+		query:  []string{"token"},
+		status: []int{http.StatusOK}, response: envelope{"collection": data.Collection{}},
+	},
+	{
+		method: http.MethodPatch, path: "/v1/collections/:id", id: "updateCollection", summary: "Update a collection", tag: "collections", permission: "movies:read",
+		body: jsonBody(struct {
+			Name        *string `json:"name"`
+			Description *string `json:"description"`
+			Visibility  *string `json:"visibility"`
+			Version     *int32  `json:"version"`
+		}{}),
+		status: []int{http.StatusOK}, response: envelope{"collection": data.Collection{}},
+		errors: []int{http.StatusConflict},
+	},
+	{
+		method: http.MethodDelete, path: "/v1/collections/:id", id: "deleteCollection", summary: "Delete a collection", tag: "collections", permission: "movies:read",
+		status: []int{http.StatusNoContent},
+	},
+	{
+		method: http.MethodPut, path: "/v1/collections/:id/items", id: "updateCollectionItems", summary: "Replace the movies of a collection", tag: "collections", permission: "movies:read",
+		body: jsonBody(struct {
+			Items []struct {
+				MovieID int64  `json:"movieId"`
+				Note    string `json:"note,omitempty"`
+			} `json:"items"`
+			Version *int32 `json:"version"`
+		}{}),
+		status: []int{http.StatusOK}, response: envelope{"collection": data.Collection{}},
+		errors: []int{http.StatusConflict},
+	},
+
+	{
+		method: http.MethodGet, path: "/v1/webhooks", id: "listWebhooks", summary: "List your webhooks", tag: "webhooks", permission: "webhooks:write",
+		status: []int{http.StatusOK}, response: envelope{"webhooks": []data.Webhook{}},
+	},
+	{
+		method: http.MethodPost, path: "/v1/webhooks", id: "createWebhook", summary: "Subscribe a webhook to events", tag: "webhooks", permission: "webhooks:write",
+		body: jsonBody(struct {
+			URL    string   `json:"url"`
+			Secret string   `json:"secret"`
+			Events []string `json:"events"`
+			Active *bool    `json:"active"`
+		}{}),
+		status: []int{http.StatusCreated}, response: envelope{"webhook": data.Webhook{}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/webhooks/:id", id: "showWebhook", summary: "Show a webhook", tag: "webhooks", permission: "webhooks:write",
+		status: []int{http.StatusOK}, response: envelope{"webhook": data.Webhook{}},
+	},
+	{
+		method: http.MethodPatch, path: "/v1/webhooks/:id", id: "updateWebhook", summary: "Update a webhook", tag: "webhooks", permission: "webhooks:write",
+		body: jsonBody(struct {
+			URL     *string  `json:"url"`
+			Secret  *string  `json:"secret"`
+			Events  []string `json:"events,omitempty"`
+			Active  *bool    `json:"active"`
+			Version *int32   `json:"version"`
+		}{}),
+		status: []int{http.StatusOK}, response: envelope{"webhook": data.Webhook{}},
+		errors: []int{http.StatusConflict},
+	},
+	{
+		method: http.MethodDelete, path: "/v1/webhooks/:id", id: "deleteWebhook", summary: "Delete a webhook", tag: "webhooks", permission: "webhooks:write",
+		status: []int{http.StatusNoContent},
+	},
+	{
+		method: http.MethodGet, path: "/v1/webhooks/:id/deliveries", id: "listWebhookDeliveries", summary: "List the deliveries of a webhook", tag: "webhooks", permission: "webhooks:write",
+		query: []string{"status", "page", "pageSize"}, sort: data.WebhookDeliveriesSortSafeList,
+		status: []int{http.StatusOK}, response: envelope{"metadata": data.Metadata{}, "deliveries": []data.WebhookDelivery{}},
+	},
+	{
+		method: http.MethodPost, path: "/v1/webhooks/:id/ping", id: "pingWebhook", summary: "Send a ping event to a webhook", tag: "webhooks", permission: "webhooks:write",
+		status: []int{http.StatusAccepted}, response: envelope{"delivery": data.WebhookDelivery{}},
+	},
+
+	{
+		method: http.MethodPost, path: "/v1/users", id: "registerUser", summary: "Register a user", tag: "users",
+		body: jsonBody(struct {
+			Name     string `json:"name"`
+			Email    string `json:"email"`
+			Password string `json:"password"`
+		}{}),
+		status: []int{http.StatusAccepted}, response: envelope{"user": data.User{}},
+	},
+	{
+		method: http.MethodPut, path: "/v1/users/activated", id: "activateUser", summary: "Activate a user", tag: "users",
+		body: jsonBody(struct {
+			Token string `json:"token"`
+		}{}),
+		status: []int{http.StatusOK}, response: envelope{"user": data.User{}},
+		errors: []int{http.StatusConflict},
+	},
+	{
+		method: http.MethodGet, path: "/v1/users/me/collections", id: "listUserCollections", summary: "List your collections", tag: "collections", permission: "movies:read",
+		query: pageQuery, sort: data.CollectionsSortSafeList,
+		status: []int{http.StatusOK}, response: envelope{"metadata": data.Metadata{}, "collections": []data.Collection{}},
+	},
+	{
+		method: http.MethodGet, path: "/v1/users/me/watchlist", id: "listWatchlist", summary: "List your watchlist", tag: "watchlists", permission: "movies:read",
+		query: pageQuery, sort: data.WatchlistSortSafeList,
+		status: []int{http.StatusOK}, response: envelope{"metadata": data.Metadata{}, "watchlist": []data.WatchlistItem{}},
+	},
+	{
+		method: http.MethodPut, path: "/v1/users/me/watchlist/:id", id: "addToWatchlist", summary: "Add a movie to your watchlist", tag: "watchlists", permission: "movies:read",
+		status: []int{http.StatusOK}, response: envelope{"item": data.WatchlistItem{}},
+	},
+	{
+		method: http.MethodDelete, path: "/v1/users/me/watchlist/:id", id: "removeFromWatchlist", summary: "Remove a movie from your watchlist", tag: "watchlists", permission: "movies:read",
+		status: []int{http.StatusNoContent},
+	},
+	{
+		method: http.MethodGet, path: "/v1/users/me/watched", id: "listWatched", summary: "List the movies you watched", tag: "watchlists", permission: "movies:read",
+		query: pageQuery, sort: data.WatchedSortSafeList,
+		status: []int{http.StatusOK}, response: envelope{"metadata": data.Metadata{}, "watched": []data.WatchedItem{}},
+	},
+	{
+		method: http.MethodPut, path: "/v1/users/me/watched/:id", id: "markWatched", summary: "Mark a movie as watched", tag: "watchlists", permission: "movies:read",
+		body: jsonBody(struct {
+			WatchedOn *string `json:"watchedOn"`
+		}{}),
+		status: []int{http.StatusOK}, response: envelope{"item": data.WatchedItem{}},
+	},
+	{
+		method: http.MethodDelete, path: "/v1/users/me/watched/:id", id: "removeWatched", summary: "Remove a movie from the ones you watched", tag: "watchlists", permission: "movies:read",
+		status: []int{http.StatusNoContent},
+	},
+
+	{
+		method: http.MethodPost, path: "/v1/tokens/authentication", id: "createAuthenticationToken", summary: "Create an authentication token", tag: "tokens",
+		secretResponse: true,
+		body: jsonBody(struct {
+			Email    string `json:"email"`
+			Password string `json:"password"`
+		}{}),
+		status: []int{http.StatusCreated}, response: envelope{"authenticationToken": data.Token{}},
+	},
+}
+
+// apiErrors describes the error responses, by status.
+var apiErrors = map[int]string{
+	http.StatusBadRequest:           "The request is malformed.",
+	http.StatusUnauthorized:         "The credentials or the authentication token are invalid, or one is required.",
+	http.StatusForbidden:            "The user account is not activated or lacks the permission.",
+	http.StatusNotFound:             "The resource does not exist.",
+	http.StatusNotAcceptable:        "None of the media types in the Accept header can represent the resource.",
+	http.StatusConflict:             "The resource was changed concurrently, or conflicts with an existing one.",
+	http.StatusPreconditionFailed:   "The If-Match header does not match the current version of the resource.",
+	http.StatusUnsupportedMediaType: "The media type of the request body is not supported.",
+	http.StatusUnprocessableEntity:  "The request failed validation, see the per-field errors.",
+	http.StatusPreconditionRequired: "The server requires an If-Match header.",
+	http.StatusTooManyRequests:      "The client sent too many requests.",
+	http.StatusInternalServerError:  "The server encountered a problem.",
+}
+
+// operationErrors returns the error statuses of the operation: those every
+// operation can fail with, those of authenticated, parameterized and body
+// accepting operations when it is one, and the ones specific to it.
+func operationErrors(op apiOperation, pathParams []string) []int {
+	statuses := []int{http.StatusTooManyRequests, http.StatusInternalServerError}
+
+	if op.permission != "" {
+		statuses = append(statuses, http.StatusUnauthorized, http.StatusForbidden)
+	}
+
+	if len(pathParams) > 0 {
+		statuses = append(statuses, http.StatusNotFound)
+	}
+
+	if op.body != nil {
+		statuses = append(statuses, http.StatusBadRequest)
+	}
+
+	if op.body != nil || len(op.query) > 0 || op.sort != nil {
+		statuses = append(statuses, http.StatusUnprocessableEntity)
+	}
+
+	if op.method == http.MethodGet && op.produces == nil {
+		statuses = append(statuses, http.StatusNotAcceptable)
+	}
+
+	return append(statuses, op.errors...)
+}
+
+// openAPIDocument builds the OpenAPI document of the API from
+// apiOperations.
+func openAPIDocument() *openapi.Document {
+	reg := openapi.NewRegistry()
+
+	reg.Define(data.Runtime(0), &openapi.Schema{Type: "string", Pattern: `^\d+ mins$`, Description: "Runtime in minutes, as in \"107 mins\"."})
+	reg.Define(json.RawMessage{}, &openapi.Schema{})
+	reg.Define(binaryFile{}, &openapi.Schema{Type: "string", Format: "binary"})
+
+	reg.Schemas["Error"] = &openapi.Schema{
+		Type:     "object",
+		Required: []string{"error"},
+		Properties: map[string]*openapi.Schema{
+			"error": {
+				Description: "A message, or messages by field for validation errors.",
+				OneOf: []*openapi.Schema{
+					{Type: "string"},
+					{Type: "object", AdditionalProperties: &openapi.Schema{Type: "string"}},
+				},
+			},
+		},
+	}
+
+	reg.Schemas["Problem"] = &openapi.Schema{
+		Type:        "object",
+		Description: "An RFC 7807 problem details document, sent when requested in the Accept header or enabled on the server.",
+		Required:    []string{"type", "title", "status", "detail", "instance", "code"},
+		Properties: map[string]*openapi.Schema{
+			"type":          {Type: "string", Format: "uri"},
+			"title":         {Type: "string"},
+			"status":        {Type: "integer"},
+			"detail":        {Type: "string"},
+			"instance":      {Type: "string"},
+			"code":          {Type: "string", Description: "Machine-readable error code, such as validation_failed."},
+			"requestId":     {Type: "string"},
+			"invalidParams": reg.Schema([]invalidParam{}),
+		},
+	}
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "Greenlight API",
+			Description: "A JSON API for retrieving and managing information about movies. Responses can also be negotiated as XML, MessagePack or, for lists, CSV through the Accept header.",
+			Version:     version,
+		},
+		Components: openapi.Components{
+			Parameters: make(map[string]*openapi.Parameter),
+			Responses:  make(map[string]*openapi.Response),
+			SecuritySchemes: map[string]openapi.SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", Description: "An authentication token from POST /v1/tokens/authentication."},
+			},
+		},
+	}
+
+	for name, param := range apiParameters {
+		p := *param
+		p.Name, p.In = name, "query"
+		doc.Components.Parameters[name] = &p
+	}
+
+	doc.Components.Parameters["Last-Event-ID"] = &openapi.Parameter{
+		Name:        "Last-Event-ID",
+		In:          "header",
+		Description: "ID of the last event received, to resume the stream from the event after it.",
+		Schema:      &openapi.Schema{Type: "integer"},
+	}
+
+	doc.Components.Parameters["Idempotency-Key"] = &openapi.Parameter{
+		Name:        "Idempotency-Key",
+		In:          "header",
+		Description: "Makes the request safe to retry: the first response for the key is replayed to retries of the same request.",
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+
+	for status, description := range apiErrors {
+		doc.Components.Responses[errorResponseName(status)] = &openapi.Response{
+			Description: description,
+			Content: map[string]openapi.MediaType{
+				"application/json": {Schema: openapi.Ref("Error")},
+				problemMediaType:   {Schema: openapi.Ref("Problem")},
+			},
+		}
+	}
+
+	doc.Components.Responses[errorResponseName(http.StatusMovedPermanently)] = &openapi.Response{
+		Description: "The movie was merged into another one, given in the Location header.",
+		Content: map[string]openapi.MediaType{
+			"application/json": {Schema: envelopeSchema(reg, envelope{"movieId": int64(0)})},
+		},
+	}
+
+	tags := make(map[string]bool)
+
+	for _, op := range apiOperations {
+		path, pathParams := openapi.Path(op.path)
+
+		operation := &openapi.Operation{
+			OperationID: op.id,
+			Summary:     op.summary,
+			Tags:        []string{op.tag},
+			Responses:   make(map[string]*openapi.Response),
+		}
+
+		tags[op.tag] = true
+
+		operation.Description = op.description
+
+		if op.permission != "" {
+			operation.Description = strings.TrimSpace(fmt.Sprintf("%s\n\nRequires the %s permission.", op.description, op.permission))
+			operation.Security = []openapi.SecurityRequirement{{"bearerAuth": {}}}
+			operation.Permissions = []string{op.permission}
+		}
+
+		for _, name := range pathParams {
+			schema := &openapi.Schema{Type: "integer", Format: "int64"}
+			if strings.Contains(op.path, "*"+name) {
+				schema = &openapi.Schema{Type: "string"}
+			}
+
+			operation.Parameters = append(operation.Parameters, &openapi.Parameter{Name: name, In: "path", Required: true, Schema: schema})
+		}
+
+		for _, name := range op.query {
+			operation.Parameters = append(operation.Parameters, &openapi.Parameter{Ref: "#/components/parameters/" + name})
+		}
+
+		for _, name := range op.headers {
+			operation.Parameters = append(operation.Parameters, &openapi.Parameter{Ref: "#/components/parameters/" + name})
+		}
+
+		if op.method != http.MethodGet && !op.secretResponse {
+			operation.Parameters = append(operation.Parameters, &openapi.Parameter{Ref: "#/components/parameters/Idempotency-Key"})
+		}
+
+		if op.sort != nil {
+			operation.Parameters = append(operation.Parameters, &openapi.Parameter{
+				Name:        "sort",
+				In:          "query",
+				Description: "Sort key, descending when prefixed with \"-\".",
+				Schema:      &openapi.Schema{Type: "string", Enum: op.sort},
+			})
+		}
+
+		if op.body != nil {
+			operation.RequestBody = &openapi.RequestBody{Required: true, Content: make(map[string]openapi.MediaType)}
+
+			for mediaType, v := range op.body {
+				operation.RequestBody.Content[mediaType] = openapi.MediaType{Schema: reg.Schema(v)}
+			}
+		}
+
+		for _, status := range op.status {
+			response := &openapi.Response{Description: http.StatusText(status)}
+
+			switch {
+			case op.produces != nil:
+				response.Content = make(map[string]openapi.MediaType)
+
+				for _, mediaType := range op.produces {
+					response.Content[mediaType] = openapi.MediaType{Schema: reg.Schema(op.response)}
+				}
+			case op.response != nil:
+				response.Content = map[string]openapi.MediaType{
+					"application/json": {Schema: envelopeSchema(reg, op.response)},
+				}
+			}
+
+			operation.Responses[strconv.Itoa(status)] = response
+		}
+
+		for _, status := range operationErrors(op, pathParams) {
+			operation.Responses[strconv.Itoa(status)] = &openapi.Response{Ref: "#/components/responses/" + errorResponseName(status)}
+		}
+
+		doc.AddOperation(op.method, path, operation)
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, openapi.Tag{Name: tag})
+	}
+
+	sort.Slice(doc.Tags, func(i, j int) bool {
+		return doc.Tags[i].Name < doc.Tags[j].Name
+	})
+
+	doc.Components.Schemas = reg.Schemas
+
+	return doc
+}
+
+// envelopeSchema returns the schema of an envelope, an object with the
+// schema of each value as a required member. Other values are described
+// by their type.
+func envelopeSchema(reg *openapi.Registry, v any) *openapi.Schema {
+	env, ok := v.(envelope)
+	if !ok {
+		return reg.Schema(v)
+	}
+
+	schema := &openapi.Schema{Type: "object", Properties: make(map[string]*openapi.Schema)}
+
+	for key, value := range env {
+		schema.Properties[key] = reg.Schema(value)
+		schema.Required = append(schema.Required, key)
+	}
+
+	sort.Strings(schema.Required)
+
+	return schema
+}
+
+func errorResponseName(status int) string {
+	return strings.ReplaceAll(http.StatusText(status), " ", "")
+}
+
+func (app *application) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	js, err := json.Marshal(openAPIDocument())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(js, '\n'))
+}
+
+// docsHandler serves a page rendering the OpenAPI document with Redoc.
+func (app *application) docsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
@@ -0,0 +1,168 @@
+//go:build unit
+// +build unit
+
+package main
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type registeredRoute struct {
+	method     string
+	path       string
+	permission string
+}
+
+// registeredRoutes returns the routes registered in routes.go, read from
+// its syntax tree so that routes behind configuration are found too.
+func registeredRoutes(t *testing.T) []registeredRoute {
+	file, err := parser.ParseFile(token.NewFileSet(), "routes.go", nil, 0)
+	require.NoError(t, err)
+
+	var routes []registeredRoute
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 3 {
+			return true
+		}
+
+		fun, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || (fun.Sel.Name != "HandlerFunc" && fun.Sel.Name != "Handler") {
+			return true
+		}
+
+		if receiver, ok := fun.X.(*ast.Ident); !ok || (receiver.Name != "router" && receiver.Name != "movies") {
+			return true
+		}
+
+		method, ok := call.Args[0].(*ast.SelectorExpr)
+		require.True(t, ok, "route method must be an http.Method constant")
+
+		path, ok := call.Args[1].(*ast.BasicLit)
+		require.True(t, ok, "route path must be a string literal")
+
+		route := registeredRoute{
+			method: strings.ToUpper(strings.TrimPrefix(method.Sel.Name, "Method")),
+		}
+
+		route.path, err = strconv.Unquote(path.Value)
+		require.NoError(t, err)
+
+		if handler, ok := call.Args[2].(*ast.CallExpr); ok {
+			if fun, ok := handler.Fun.(*ast.SelectorExpr); ok && fun.Sel.Name == "requirePermission" {
+				route.permission, err = strconv.Unquote(handler.Args[0].(*ast.BasicLit).Value)
+				require.NoError(t, err)
+			}
+		}
+
+		routes = append(routes, route)
+
+		return true
+	})
+
+	require.NotEmpty(t, routes)
+
+	return routes
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	routes := registeredRoutes(t)
+
+	for _, route := range routes {
+		var found *apiOperation
+
+		for i := range apiOperations {
+			if apiOperations[i].method == route.method && apiOperations[i].path == route.path {
+				found = &apiOperations[i]
+			}
+		}
+
+		if found == nil {
+			t.Errorf("%s %s has no entry in apiOperations", route.method, route.path)
+			continue
+		}
+
+		require.Equal(t, route.permission, found.permission, "permission of %s %s", route.method, route.path)
+	}
+
+	for _, op := range apiOperations {
+		registered := false
+
+		for _, route := range routes {
+			if route.method == op.method && route.path == op.path {
+				registered = true
+			}
+		}
+
+		require.True(t, registered, "%s %s in apiOperations is not registered in routes", op.method, op.path)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	doc := openAPIDocument()
+
+	js, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	var tree map[string]any
+	require.NoError(t, json.Unmarshal(js, &tree))
+
+	components := tree["components"].(map[string]any)
+
+	var walk func(v any)
+
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				parts := strings.Split(strings.TrimPrefix(ref, "#/components/"), "/")
+				require.Len(t, parts, 2, ref)
+
+				section, ok := components[parts[0]].(map[string]any)
+				require.True(t, ok, ref)
+				require.Contains(t, section, parts[1], ref)
+			}
+
+			for _, value := range v {
+				walk(value)
+			}
+		case []any:
+			for _, value := range v {
+				walk(value)
+			}
+		}
+	}
+
+	walk(tree)
+
+	ids := make(map[string]bool)
+
+	for _, op := range apiOperations {
+		require.False(t, ids[op.id], "duplicate operation id %s", op.id)
+		ids[op.id] = true
+	}
+
+	movies := doc.Operation("GET", "/v1/movies")
+	require.NotNil(t, movies)
+	require.Equal(t, []string{"movies:read"}, movies.Permissions)
+	require.Contains(t, movies.Responses, "422")
+
+	show := doc.Operation("GET", "/v1/movies/{id}")
+	require.NotNil(t, show)
+	require.Equal(t, "path", show.Parameters[0].In)
+	require.Equal(t, "id", show.Parameters[0].Name)
+
+	movie := doc.Components.Schemas["Movie"]
+	require.NotNil(t, movie)
+	require.Equal(t, "string", movie.Properties["runtime"].Type)
+	require.NotContains(t, movie.Properties, "createdAt")
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/brGuirra/greenlight/internal/data"
+	"github.com/brGuirra/greenlight/internal/patch"
+)
+
+const (
+	mergePatchMediaType = "application/merge-patch+json"
+	jsonPatchMediaType  = "application/json-patch+json"
+)
+
+// movieAcceptPatch lists the media types accepted when patching a movie,
+// advertised in the Accept-Patch header.
+const movieAcceptPatch = "application/json, " + mergePatchMediaType + ", " + jsonPatchMediaType
+
+// movieDocument is the editable part of a movie, the document that JSON
+// Merge Patch and JSON Patch requests are applied to.
+type movieDocument struct {
+	Title   string       `json:"title"`
+	Year    int32        `json:"year"`
+	Runtime data.Runtime `json:"runtime"`
+	Genres  []string     `json:"genres"`
+}
+
+// applyMoviePatch applies a JSON Merge Patch or a JSON Patch, as given by
+// the media type, to the movie. Errors from malformed patches and failed
+// "test" operations wrap patch.ErrMalformedPatch and patch.ErrTestFailed,
+// any other error means the patch cannot be applied to the movie.
+func applyMoviePatch(movie *data.Movie, mediaType string, body []byte) error {
+	doc, err := json.Marshal(movieDocument{
+		Title:   movie.Title,
+		Year:    movie.Year,
+		Runtime: movie.Runtime,
+		Genres:  movie.Genres,
+	})
+	if err != nil {
+		return err
+	}
+
+	var result []byte
+
+	switch mediaType {
+	case mergePatchMediaType:
+		result, err = patch.Merge(doc, body)
+	case jsonPatchMediaType:
+		result, err = patch.Apply(doc, body)
+	default:
+		return fmt.Errorf("unsupported patch media type %q", mediaType)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var patched movieDocument
+
+	dec := json.NewDecoder(bytes.NewReader(result))
+	dec.DisallowUnknownFields()
+
+	err = dec.Decode(&patched)
+	if err != nil {
+		var unmarshalTypeError *json.UnmarshalTypeError
+
+		switch {
+		case errors.As(err, &unmarshalTypeError) && unmarshalTypeError.Field != "":
+			return fmt.Errorf("patched movie contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return fmt.Errorf("patched movie contains unknown key %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+		default:
+			return fmt.Errorf("patched movie is invalid: %v", err)
+		}
+	}
+
+	movie.Title = patched.Title
+	movie.Year = patched.Year
+	movie.Runtime = patched.Runtime
+	movie.Genres = patched.Genres
+
+	return nil
+}
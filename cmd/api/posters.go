@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"time"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/brGuirra/greenlight/internal/data"
+	"github.com/brGuirra/greenlight/internal/imaging"
+	"github.com/brGuirra/greenlight/internal/validator"
+)
+
+// posterMaxPixels caps the decoded size of a poster, so a small but highly
+// compressed upload cannot exhaust the server memory.
+const posterMaxPixels = 40_000_000
+
+var (
+	posterContentTypes = map[string]string{
+		"image/jpeg": "jpg",
+		"image/png":  "png",
+		"image/gif":  "gif",
+	}
+
+	posterThumbnailWidths = map[string]int{
+		"small":  185,
+		"medium": 342,
+	}
+)
+
+// uploadMoviePosterHandler stores the poster sent in the "poster" field of
+// a multipart form, along with its thumbnails, replacing any previous one.
+func (app *application) uploadMoviePosterHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	movie, err := app.models.Movies.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	content, err := app.readPosterPart(w, r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	v.Check(int64(len(content)) <= app.config.posters.maxBytes, "poster", fmt.Sprintf("must not be larger than %d bytes", app.config.posters.maxBytes))
+
+	contentType := http.DetectContentType(content)
+	ext, supported := posterContentTypes[contentType]
+
+	v.Check(supported, "poster", "must be a JPEG, PNG or GIF image")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	dimensions, _, err := image.DecodeConfig(bytes.NewReader(content))
+
+	v.Check(err == nil, "poster", "must be a valid image")
+	v.Check(err != nil || dimensions.Width*dimensions.Height <= posterMaxPixels, "poster", "must not be larger than 40 megapixels")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		v.AddError("poster", "must be a valid image")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	token, err := posterToken()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	prefix := fmt.Sprintf("posters/%d/%s", movie.ID, token)
+
+	poster := &data.Poster{}
+
+	put := func(name, contentType string, body []byte) (string, error) {
+		key := prefix + "/" + name
+
+		err := app.storage.Put(ctx, key, bytes.NewReader(body), contentType)
+		if err != nil {
+			return "", err
+		}
+
+		poster.Keys = append(poster.Keys, key)
+
+		return app.storage.URL(key), nil
+	}
+
+	poster.Original, err = put("original."+ext, contentType, content)
+
+	for name, width := range posterThumbnailWidths {
+		if err != nil {
+			break
+		}
+
+		var buf bytes.Buffer
+
+		err = jpeg.Encode(&buf, imaging.Resize(img, width), &jpeg.Options{Quality: 85})
+		if err != nil {
+			break
+		}
+
+		var url string
+
+		url, err = put(name+".jpg", "image/jpeg", buf.Bytes())
+
+		switch name {
+		case "small":
+			poster.Small = url
+		case "medium":
+			poster.Medium = url
+		}
+	}
+
+	if err != nil {
+		app.deletePosterFiles(poster)
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	previous, err := app.models.Movies.SetPoster(&movie, poster, app.contextGetUser(r).ID)
+	if err != nil {
+		app.deletePosterFiles(poster)
+
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.deletePosterFiles(previous)
+
+	err = app.writeResponse(w, r, http.StatusOK, envelope{"movie": movie}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteMoviePosterHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	movie, err := app.models.Movies.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if movie.Poster == nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	previous, err := app.models.Movies.SetPoster(&movie, nil, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.deletePosterFiles(previous)
+
+	app.noContentResponse(w)
+}
+
+// readPosterPart returns the content of the "poster" part of a multipart
+// request. The content is read up to one byte past the configured limit, so
+// oversized posters can be reported as a validation error.
+func (app *application) readPosterPart(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	// Large posters can take longer to upload than the server read timeout.
+	_ = http.NewResponseController(w).SetReadDeadline(time.Now().Add(time.Minute))
+
+	r.Body = http.MaxBytesReader(w, r.Body, app.posterBodyLimit())
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.New("body must be a multipart form")
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New(`body must contain a "poster" file`)
+		}
+
+		if err != nil {
+			var maxBytesError *http.MaxBytesError
+
+			if errors.As(err, &maxBytesError) {
+				return nil, fmt.Errorf("body must not be longer than %d bytes", maxBytesError.Limit)
+			}
+
+			return nil, errors.New("body contains a badly-formed multipart form")
+		}
+
+		if part.FormName() != "poster" {
+			part.Close()
+			continue
+		}
+
+		content, err := io.ReadAll(io.LimitReader(part, app.config.posters.maxBytes+1))
+		if err != nil {
+			var maxBytesError *http.MaxBytesError
+
+			if errors.As(err, &maxBytesError) {
+				return nil, fmt.Errorf("body must not be longer than %d bytes", maxBytesError.Limit)
+			}
+
+			return nil, err
+		}
+
+		return content, nil
+	}
+}
+
+// posterBodyLimit returns the largest poster upload request body, leaving
+// room for the multipart boundaries and headers around the file.
+func (app *application) posterBodyLimit() int64 {
+	return app.config.posters.maxBytes + 65_536
+}
+
+// deletePosterFiles removes the files of a replaced or failed poster. The
+// poster is no longer referenced, so failures are only logged.
+func (app *application) deletePosterFiles(poster *data.Poster) {
+	if poster == nil {
+		return
+	}
+
+	for _, key := range poster.Keys {
+		err := app.storage.Delete(context.Background(), key)
+		if err != nil {
+			app.logger.PrintError(err, map[string]string{"key": key})
+		}
+	}
+}
+
+// posterToken returns a random path segment, so every upload is stored
+// under new URLs and cached copies of a replaced poster are never served.
+func posterToken() (string, error) {
+	b := make([]byte, 8)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
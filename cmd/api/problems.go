@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/brGuirra/greenlight/internal/render"
+)
+
+const (
+	problemMediaType     = "application/problem+json"
+	problemTypePrefix    = "urn:greenlight:problem:"
+	validationFailedInfo = "the request contains invalid parameters, see invalidParams for details"
+)
+
+// invalidParam describes a field that failed validation.
+type invalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// wantsProblemDetails reports whether errors are sent as problem details,
+// because the server is configured to or because the client explicitly
+// lists application/problem+json in its Accept header. Wildcard media
+// ranges do not opt in, so existing clients keep the {"error": ...} format.
+func (app *application) wantsProblemDetails(r *http.Request) bool {
+	if app.config.problems.enabled {
+		return true
+	}
+
+	accept := r.Header.Get("Accept")
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && mediaType == problemMediaType {
+			return len(render.Negotiate(accept, []string{problemMediaType})) > 0
+		}
+	}
+
+	return false
+}
+
+// writeProblem sends an RFC 7807 problem details document. The type is a
+// URN built from the machine-readable code, which is also sent on its own,
+// and validation errors are listed per field in invalidParams. The extra
+// members are added as extension members.
+func (app *application) writeProblem(w http.ResponseWriter, r *http.Request, status int, code string, message any, extra envelope) error {
+	problem := envelope{
+		"type":     problemTypePrefix + code,
+		"title":    http.StatusText(status),
+		"status":   status,
+		"instance": r.URL.Path,
+		"code":     code,
+	}
+
+	switch message := message.(type) {
+	case map[string]string:
+		problem["detail"] = validationFailedInfo
+		problem["invalidParams"] = invalidParams(message)
+	default:
+		problem["detail"] = message
+	}
+
+	if id := app.contextGetRequestID(r); id != "" {
+		problem["requestId"] = id
+	}
+
+	for key, value := range extra {
+		problem[key] = value
+	}
+
+	js, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("Content-Type", problemMediaType)
+	w.WriteHeader(status)
+
+	_, err = w.Write(append(js, '\n'))
+	return err
+}
+
+// invalidParams converts the errors of a validator into a list sorted by
+// field name.
+func invalidParams(errors map[string]string) []invalidParam {
+	params := make([]invalidParam, 0, len(errors))
+
+	for name, reason := range errors {
+		params = append(params, invalidParam{Name: name, Reason: reason})
+	}
+
+	sort.Slice(params, func(i, j int) bool {
+		return params[i].Name < params[j].Name
+	})
+
+	return params
+}
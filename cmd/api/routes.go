@@ -4,6 +4,7 @@ import (
 	"expvar"
 	"net/http"
 
+	"github.com/brGuirra/greenlight/internal/storage"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -15,19 +16,114 @@ func (app *application) routes() http.Handler {
 	router.MethodNotAllowed = http.HandlerFunc(app.methodNotAllowedResponse)
 
 	router.HandlerFunc(http.MethodGet, "/v1/healthcheck", app.healthcheckHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/openapi.json", app.openAPIHandler)
+
+	if app.config.docs.enabled {
+		router.HandlerFunc(http.MethodGet, "/v1/docs", app.docsHandler)
+	}
+
+	if local, ok := app.storage.(*storage.Local); ok {
+		router.Handler(http.MethodGet, "/media/*filepath", http.StripPrefix("/media", http.FileServer(local.Files())))
+	}
+
+	// The router does not allow a static segment next to the /v1/movies/:id
+	// wildcard, so the static movie routes have a router of their own,
+	// matched first.
+	movies := httprouter.New()
+
+	movies.MethodNotAllowed = http.HandlerFunc(app.methodNotAllowedResponse)
+
+	movies.HandlerFunc(http.MethodGet, "/v1/movies/events", app.requirePermission("movies:read", app.streamMovieEventsHandler))
+	movies.HandlerFunc(http.MethodPost, "/v1/movies/batch", app.requirePermission("movies:write", app.batchMoviesHandler))
+	movies.HandlerFunc(http.MethodGet, "/v1/movies/export", app.requirePermission("movies:read", app.exportMoviesHandler))
+	movies.HandlerFunc(http.MethodPost, "/v1/movies/import", app.requirePermission("movies:write", app.importMoviesHandler))
 
 	router.HandlerFunc(http.MethodGet, "/v1/movies", app.requirePermission("movies:read", app.listMoviesHandler))
 	router.HandlerFunc(http.MethodPost, "/v1/movies", app.requirePermission("movies:write", app.createMoviesHandler))
 	router.HandlerFunc(http.MethodGet, "/v1/movies/:id", app.requirePermission("movies:read", app.showMovieHandler))
 	router.HandlerFunc(http.MethodPatch, "/v1/movies/:id", app.requirePermission("movies:write", app.updateMovieHandler))
 	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id", app.requirePermission("movies:write", app.deleteMovieHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/restore", app.requirePermission("movies:write", app.restoreMovieHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/merge", app.requirePermission("movies:merge", app.mergeMovieHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/trash/movies", app.requirePermission("movies:write", app.listTrashedMoviesHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/imports/:id", app.requirePermission("movies:write", app.showImportHandler))
+
+	router.HandlerFunc(http.MethodPut, "/v1/movies/:id/poster", app.requirePermission("movies:write", app.uploadMoviePosterHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id/poster", app.requirePermission("movies:write", app.deleteMoviePosterHandler))
+
+	router.HandlerFunc(http.MethodPut, "/v1/movies/:id/rating", app.requirePermission("movies:read", app.rateMovieHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id/rating", app.requirePermission("movies:read", app.deleteMovieRatingHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/reviews", app.requirePermission("movies:read", app.listReviewsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/reviews", app.requirePermission("movies:read", app.createReviewHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/movies/:id/reviews/:reviewId", app.requirePermission("movies:read", app.updateReviewHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/movies/:id/reviews/:reviewId", app.requirePermission("movies:read", app.deleteReviewHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/movies/:id/reviews/:reviewId/visibility", app.requirePermission("reviews:moderate", app.moderateReviewHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id/revisions", app.requirePermission("movies:read", app.listMovieRevisionsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/movies/:id/revisions/:version/restore", app.requirePermission("movies:write", app.restoreMovieRevisionHandler))
+
+	router.HandlerFunc(http.MethodPut, "/v1/movies/:id/credits", app.requirePermission("movies:write", app.updateMovieCreditsHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/movies/:id/titles", app.requirePermission("movies:write", app.updateMovieTitlesHandler))
 
-	router.HandlerFunc(http.MethodPost, "/v1/users", app.registerUserHandler)
-	router.HandlerFunc(http.MethodPut, "/v1/users/activated", app.activateUserHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/people", app.requirePermission("movies:read", app.listPeopleHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/people", app.requirePermission("movies:write", app.createPersonHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/people/:id", app.requirePermission("movies:read", app.showPersonHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/people/:id", app.requirePermission("movies:write", app.updatePersonHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/people/:id", app.requirePermission("movies:write", app.deletePersonHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/genres", app.requirePermission("movies:read", app.listGenresHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/genres", app.requirePermission("genres:write", app.createGenreHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/genres/:id", app.requirePermission("genres:write", app.updateGenreHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/genres/:id", app.requirePermission("genres:write", app.deleteGenreHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/collections", app.listPublicCollectionsHandler)
+	router.HandlerFunc(http.MethodPost, "/v1/collections", app.requirePermission("movies:read", app.createCollectionHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/collections/:id", app.showCollectionHandler)
+	router.HandlerFunc(http.MethodPatch, "/v1/collections/:id", app.requirePermission("movies:read", app.updateCollectionHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/collections/:id", app.requirePermission("movies:read", app.deleteCollectionHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/collections/:id/items", app.requirePermission("movies:read", app.updateCollectionItemsHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/webhooks", app.requirePermission("webhooks:write", app.listWebhooksHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/webhooks", app.requirePermission("webhooks:write", app.createWebhookHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/webhooks/:id", app.requirePermission("webhooks:write", app.showWebhookHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/webhooks/:id", app.requirePermission("webhooks:write", app.updateWebhookHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/webhooks/:id", app.requirePermission("webhooks:write", app.deleteWebhookHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/webhooks/:id/deliveries", app.requirePermission("webhooks:write", app.listWebhookDeliveriesHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/webhooks/:id/ping", app.requirePermission("webhooks:write", app.pingWebhookHandler))
+
+	router.HandlerFunc(http.MethodPost, "/v1/users", app.idempotency(jsonMaxBytes, app.registerUserHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/users/activated", app.idempotency(jsonMaxBytes, app.activateUserHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/collections", app.requirePermission("movies:read", app.listUserCollectionsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/watchlist", app.requirePermission("movies:read", app.listWatchlistHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/users/me/watchlist/:id", app.requirePermission("movies:read", app.addToWatchlistHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/users/me/watchlist/:id", app.requirePermission("movies:read", app.removeFromWatchlistHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/users/me/watched", app.requirePermission("movies:read", app.listWatchedHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/users/me/watched/:id", app.requirePermission("movies:read", app.markWatchedHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/users/me/watched/:id", app.requirePermission("movies:read", app.removeWatchedHandler))
 
 	router.HandlerFunc(http.MethodPost, "/v1/tokens/authentication", app.createAuthenticationTokenHandler)
 
 	router.Handler(http.MethodGet, "/v1/metrics", expvar.Handler())
 
-	return app.metrics(app.recoverPanic(app.enableCORS(app.rateLimit(app.authenticate(router)))))
+	return app.metrics(app.requestID(app.recoverPanic(app.enableCORS(app.rateLimit(app.authenticate(staticFirst(movies, router)))))))
+}
+
+// staticFirst passes the requests for a path routed by static to it, which
+// answers with a 405 for the methods it has no route for, and the others to
+// router.
+func staticFirst(static, router *httprouter.Router) http.Handler {
+	methods := []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, method := range methods {
+			if handle, _, _ := static.Lookup(method, r.URL.Path); handle != nil {
+				static.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		router.ServeHTTP(w, r)
+	})
 }
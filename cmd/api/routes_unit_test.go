@@ -0,0 +1,50 @@
+//go:build unit
+// +build unit
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticFirst(t *testing.T) {
+	static := httprouter.New()
+	static.HandlerFunc(http.MethodGet, "/v1/movies/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("events"))
+	})
+
+	router := httprouter.New()
+	router.HandlerFunc(http.MethodGet, "/v1/movies/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("movie " + httprouter.ParamsFromContext(r.Context()).ByName("id")))
+	})
+
+	handler := staticFirst(static, router)
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{http.MethodGet, "/v1/movies/events", http.StatusOK, "events"},
+		{http.MethodGet, "/v1/movies/7", http.StatusOK, "movie 7"},
+		{http.MethodPost, "/v1/movies/events", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+		require.Equal(t, tt.status, rec.Code, "%s %s", tt.method, tt.path)
+
+		if tt.body != "" {
+			require.Equal(t, tt.body, rec.Body.String())
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 )
@@ -20,6 +21,13 @@ func (app *application) serve() error {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	srv.RegisterOnShutdown(app.events.close)
+
+	// The periodic tasks stop with the server, so they are not cut off in
+	// the middle of a run.
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
 	shutdownError := make(chan error)
 
 	go func() {
@@ -43,10 +51,18 @@ func (app *application) serve() error {
 			"addr": srv.Addr,
 		})
 
+		stop()
 		app.wg.Wait()
 		shutdownError <- nil
 	}()
 
+	app.background(func() { app.failStaleImports(ctx) })
+	app.background(func() { app.purgeTrash(ctx) })
+	app.background(func() { app.purgeIdempotencyKeys(ctx) })
+	app.background(func() { app.deliverWebhooks(ctx) })
+	app.background(func() { app.listenMovieEvents(ctx) })
+	app.background(func() { app.trimMovieEvents(ctx) })
+
 	app.logger.PrintInfo("starting server", map[string]string{
 		"addr": srv.Addr,
 		"env":  app.config.env,
@@ -68,3 +84,34 @@ func (app *application) serve() error {
 
 	return nil
 }
+
+// purgeTrash periodically removes the movies that have been soft-deleted for
+// longer than the configured retention period, until the context is done.
+func (app *application) purgeTrash(ctx context.Context) {
+	ticker := time.NewTicker(app.config.trash.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, posters, err := app.models.Movies.Purge(app.config.trash.retention)
+		if err != nil {
+			app.logger.PrintError(err, nil)
+			continue
+		}
+
+		for _, poster := range posters {
+			app.deletePosterFiles(poster)
+		}
+
+		if purged > 0 {
+			app.logger.PrintInfo("purged deleted movies", map[string]string{
+				"count": strconv.FormatInt(purged, 10),
+			})
+		}
+	}
+}
@@ -60,7 +60,7 @@ func (app *application) createAuthenticationTokenHandler(w http.ResponseWriter,
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusCreated, envelope{"authenticationToken": token}, nil)
+	err = app.writeResponse(w, r, http.StatusCreated, envelope{"authenticationToken": token}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
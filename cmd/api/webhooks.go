@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/data"
+	"github.com/brGuirra/greenlight/internal/validator"
+	"github.com/brGuirra/greenlight/internal/webhook"
+)
+
+// webhookBatchSize is how many deliveries a worker claims at once.
+const webhookBatchSize = 20
+
+func (app *application) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		URL    string   `json:"url"`
+		Secret string   `json:"secret"`
+		Events []string `json:"events"`
+		Active *bool    `json:"active"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	webhook := &data.Webhook{
+		UserID: app.contextGetUser(r).ID,
+		URL:    input.URL,
+		Secret: input.Secret,
+		Events: input.Events,
+		Active: true,
+	}
+
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+
+	v := validator.New()
+
+	if data.ValidateWebhook(v, webhook); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Webhooks.Insert(webhook)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/webhooks/%d", webhook.ID))
+
+	err = app.writeResponse(w, r, http.StatusCreated, envelope{"webhook": webhook}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := app.models.Webhooks.GetAllForUser(app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeResponse(w, r, http.StatusOK, envelope{"webhooks": webhooks}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := app.readOwnedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.writeResponse(w, r, http.StatusOK, envelope{"webhook": webhook}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := app.readOwnedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		URL     *string  `json:"url"`
+		Secret  *string  `json:"secret"`
+		Events  []string `json:"events"`
+		Active  *bool    `json:"active"`
+		Version *int32   `json:"version"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Version != nil && *input.Version != webhook.Version {
+		app.editConflictResponse(w, r)
+		return
+	}
+
+	if input.URL != nil {
+		webhook.URL = *input.URL
+	}
+
+	if input.Secret != nil {
+		webhook.Secret = *input.Secret
+	}
+
+	if input.Events != nil {
+		webhook.Events = input.Events
+	}
+
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+
+	v := validator.New()
+
+	if data.ValidateWebhook(v, webhook); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Webhooks.Update(webhook)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeResponse(w, r, http.StatusOK, envelope{"webhook": webhook}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := app.readOwnedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.models.Webhooks.Delete(webhook.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.noContentResponse(w)
+}
+
+func (app *application) listWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := app.readOwnedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Status string
+		data.Filters
+	}
+
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	input.Status = app.readString(qs, "status", "")
+	input.Page = app.readInt(qs, "page", 1, v)
+	input.PageSize = app.readInt(qs, "pageSize", 20, v)
+	input.Sort = app.readString(qs, "sort", "-id")
+	input.SortSafeList = data.WebhookDeliveriesSortSafeList
+
+	if input.Status != "" {
+		v.Check(validator.PermittedValue(input.Status, data.WebhookDeliveryStatuses...), "status", "must be one of pending, delivered or failed")
+	}
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	deliveries, metadata, err := app.models.WebhookDeliveries.GetAllForWebhook(webhook.ID, input.Status, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeResponse(w, r, http.StatusOK, envelope{"metadata": metadata, "deliveries": deliveries}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// pingWebhookHandler queues a ping event for the webhook, whatever events it
+// is subscribed to, so that subscribers can check they receive and verify
+// deliveries. The outcome shows in the delivery log.
+func (app *application) pingWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := app.readOwnedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := app.models.WebhookDeliveries.EnqueueFor(webhook.ID, data.EventPing, envelope{"webhookId": webhook.ID})
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeResponse(w, r, http.StatusAccepted, envelope{"delivery": delivery}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// readOwnedWebhook loads the webhook addressed by the ":id" route parameter
+// and checks that it belongs to the current user, writing the error response
+// itself when it cannot.
+func (app *application) readOwnedWebhook(w http.ResponseWriter, r *http.Request) (*data.Webhook, bool) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	webhook, err := app.models.Webhooks.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	if webhook.UserID != app.contextGetUser(r).ID {
+		app.notPermittedResponse(w, r)
+		return nil, false
+	}
+
+	return webhook, true
+}
+
+// deliverWebhooks periodically sends the pending webhook deliveries that are
+// due. Failed attempts are retried with an exponential backoff until the
+// configured number of attempts is reached. It stops once the context is
+// done, after the sends in progress complete.
+func (app *application) deliverWebhooks(ctx context.Context) {
+	ticker := time.NewTicker(app.config.webhooks.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// The lease outlasts the sends of a batch, which run concurrently.
+		deliveries, err := app.models.WebhookDeliveries.Claim(webhookBatchSize, 2*app.config.webhooks.timeout)
+		if err != nil {
+			app.logger.PrintError(err, nil)
+			continue
+		}
+
+		var wg sync.WaitGroup
+
+		for i := range deliveries {
+			wg.Add(1)
+
+			go func(delivery *data.DueDelivery) {
+				defer wg.Done()
+
+				app.deliverWebhook(delivery)
+			}(&deliveries[i])
+		}
+
+		wg.Wait()
+	}
+}
+
+// deliverWebhook sends a claimed delivery and records the outcome.
+func (app *application) deliverWebhook(delivery *data.DueDelivery) {
+	body, err := json.Marshal(struct {
+		ID        int64           `json:"id"`
+		Event     string          `json:"event"`
+		CreatedAt time.Time       `json:"createdAt"`
+		Data      json.RawMessage `json:"data"`
+	}{delivery.ID, delivery.Event, delivery.CreatedAt, delivery.Payload})
+	if err != nil {
+		app.logger.PrintError(err, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), app.config.webhooks.timeout)
+	defer cancel()
+
+	status, err := app.webhooks.Send(ctx, webhook.Delivery{
+		ID:     delivery.ID,
+		Event:  delivery.Event,
+		URL:    delivery.URL,
+		Secret: delivery.Secret,
+		Body:   body,
+	})
+
+	now := time.Now()
+
+	delivery.LastStatusCode = status
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		delivery.Status = data.DeliveryDelivered
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= app.config.webhooks.maxAttempts:
+		delivery.Status = data.DeliveryFailed
+		delivery.LastError = err.Error()
+	default:
+		delivery.Status = data.DeliveryPending
+		delivery.NextAttemptAt = now.Add(webhook.Backoff(delivery.Attempts))
+		delivery.LastError = err.Error()
+	}
+
+	err = app.models.WebhookDeliveries.UpdateResult(&delivery.WebhookDelivery)
+	if err != nil {
+		app.logger.PrintError(err, map[string]string{
+			"deliveryId": strconv.FormatInt(delivery.ID, 10),
+		})
+	}
+}
@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS genres (
+    id bigserial PRIMARY KEY,
+    created_at timestamp (0) with time zone NOT NULL DEFAULT NOW(),
+    slug text UNIQUE NOT NULL,
+    name text NOT NULL,
+    aliases text [] NOT NULL DEFAULT '{}',
+    version integer NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS genres_aliases_idx ON genres USING gin (aliases);
+
+INSERT INTO genres (slug, name, aliases)
+VALUES
+('action', 'Action', '{}'),
+('adventure', 'Adventure', '{}'),
+('animation', 'Animation', '{"animated", "cartoon"}'),
+('biography', 'Biography', '{"biopic"}'),
+('comedy', 'Comedy', '{}'),
+('crime', 'Crime', '{}'),
+('documentary', 'Documentary', '{}'),
+('drama', 'Drama', '{}'),
+('family', 'Family', '{}'),
+('fantasy', 'Fantasy', '{}'),
+('history', 'History', '{"historical"}'),
+('horror', 'Horror', '{}'),
+('music', 'Music', '{"musical"}'),
+('mystery', 'Mystery', '{}'),
+('romance', 'Romance', '{"romantic"}'),
+('sci-fi', 'Science Fiction', '{"science fiction", "science-fiction", "scifi", "sf"}'),
+('thriller', 'Thriller', '{}'),
+('war', 'War', '{}'),
+('western', 'Western', '{}');
+
+INSERT INTO genres (slug, name)
+SELECT DISTINCT trim(BOTH '-' FROM regexp_replace(lower(trim(g)), '[^a-z0-9]+', '-', 'g')), initcap(trim(g))
+FROM movies, unnest(movies.genres) AS g
+WHERE NOT EXISTS (
+    SELECT 1 FROM genres
+    WHERE genres.slug = trim(BOTH '-' FROM regexp_replace(lower(trim(g)), '[^a-z0-9]+', '-', 'g'))
+    OR lower(trim(g)) = ANY(genres.aliases)
+)
+ON CONFLICT (slug) DO NOTHING;
+
+UPDATE movies SET genres = ARRAY(
+    SELECT genres.slug
+    FROM unnest(movies.genres) WITH ORDINALITY AS m(name, position)
+    INNER JOIN genres
+    ON genres.slug = trim(BOTH '-' FROM regexp_replace(lower(trim(m.name)), '[^a-z0-9]+', '-', 'g'))
+    OR lower(trim(m.name)) = ANY(genres.aliases)
+    GROUP BY genres.slug
+    ORDER BY min(m.position)
+);
+
+INSERT INTO permissions (code)
+VALUES
+('genres:write');
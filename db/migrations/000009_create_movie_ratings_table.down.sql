@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS movies_rating_idx;
+
+ALTER TABLE movies DROP COLUMN IF EXISTS rating_count;
+ALTER TABLE movies DROP COLUMN IF EXISTS rating;
+
+DROP TABLE IF EXISTS movie_ratings;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS collections (
+    id bigserial PRIMARY KEY,
+    created_at timestamp (0) with time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp (0) with time zone NOT NULL DEFAULT NOW(),
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    name text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    visibility text NOT NULL DEFAULT 'private',
+    share_token text UNIQUE NOT NULL,
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT collections_visibility_check CHECK (visibility IN ('private', 'unlisted', 'public'))
+);
+
+CREATE INDEX IF NOT EXISTS collections_user_id_idx ON collections (user_id);
+CREATE INDEX IF NOT EXISTS collections_visibility_idx ON collections (visibility);
+
+CREATE TABLE IF NOT EXISTS collection_items (
+    collection_id bigint NOT NULL REFERENCES collections ON DELETE CASCADE,
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    position integer NOT NULL,
+    note text NOT NULL DEFAULT '',
+    PRIMARY KEY (collection_id, movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS collection_items_position_idx ON collection_items (collection_id, position);
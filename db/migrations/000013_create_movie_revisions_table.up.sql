@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS movie_revisions (
+    id bigserial PRIMARY KEY,
+    created_at timestamp (0) with time zone NOT NULL DEFAULT NOW(),
+    movie_id bigint NOT NULL,
+    version integer NOT NULL,
+    title text NOT NULL,
+    year integer NOT NULL,
+    runtime integer NOT NULL,
+    genres text [] NOT NULL,
+    action text NOT NULL,
+    user_id bigint REFERENCES users ON DELETE SET NULL,
+    UNIQUE (movie_id, version),
+    CONSTRAINT movie_revisions_action_check CHECK (action IN ('update', 'delete'))
+);
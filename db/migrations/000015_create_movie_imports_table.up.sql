@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS movie_imports (
+    id bigserial PRIMARY KEY,
+    created_at timestamp (0) with time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp (0) with time zone NOT NULL DEFAULT NOW(),
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    format text NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    total integer NOT NULL DEFAULT 0,
+    created integer NOT NULL DEFAULT 0,
+    failed integer NOT NULL DEFAULT 0,
+    report jsonb NOT NULL DEFAULT '[]',
+    CONSTRAINT movie_imports_format_check CHECK (format IN ('csv', 'ndjson')),
+    CONSTRAINT movie_imports_status_check CHECK (status IN ('pending', 'running', 'completed'))
+);
+
+CREATE INDEX IF NOT EXISTS movie_imports_user_id_idx ON movie_imports (user_id);
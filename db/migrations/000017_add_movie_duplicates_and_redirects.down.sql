@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE code = 'movies:merge';
+
+DROP TABLE IF EXISTS movie_redirects;
+
+DROP INDEX IF EXISTS movies_title_trgm_idx;
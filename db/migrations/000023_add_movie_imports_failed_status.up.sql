@@ -0,0 +1,3 @@
+ALTER TABLE movie_imports DROP CONSTRAINT IF EXISTS movie_imports_status_check;
+
+ALTER TABLE movie_imports ADD CONSTRAINT movie_imports_status_check CHECK (status IN ('pending', 'running', 'completed', 'failed'));
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS movie_events_assign_position ON movie_events;
+
+DROP FUNCTION IF EXISTS movie_events_assign_position();
+
+DROP INDEX IF EXISTS movie_events_position_idx;
+
+ALTER TABLE movie_events DROP COLUMN IF EXISTS position;
+
+DROP SEQUENCE IF EXISTS movie_events_position_seq;
@@ -0,0 +1,376 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/validator"
+	"github.com/lib/pq"
+)
+
+const (
+	VisibilityPrivate  = "private"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPublic   = "public"
+)
+
+var (
+	CollectionsSortSafeList = []string{"id", "name", "-id", "-name"}
+	CollectionVisibilities  = []string{VisibilityPrivate, VisibilityUnlisted, VisibilityPublic}
+)
+
+type Collection struct {
+	ID          int64            `json:"id"`
+	OwnerID     int64            `json:"ownerId"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Visibility  string           `json:"visibility"`
+	ShareToken  string           `json:"shareToken,omitempty"`
+	Items       []CollectionItem `json:"items,omitempty"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	UpdatedAt   time.Time        `json:"updatedAt"`
+	Version     int32            `json:"version"`
+}
+
+type CollectionItem struct {
+	Movie    Movie  `json:"movie"`
+	Position int    `json:"position"`
+	Note     string `json:"note,omitempty"`
+}
+
+// VisibleTo reports whether the collection can be read by the user. Public
+// collections are visible to everyone, unlisted ones to whoever holds the
+// share token and private ones only to their owner.
+func (c *Collection) VisibleTo(user *User, shareToken string) bool {
+	switch {
+	case !user.IsAnonymous() && user.ID == c.OwnerID:
+		return true
+	case c.Visibility == VisibilityPublic:
+		return true
+	case c.Visibility == VisibilityUnlisted:
+		return shareToken != "" && shareToken == c.ShareToken
+	default:
+		return false
+	}
+}
+
+// TODO: Test at handler level
+func ValidateCollection(v *validator.Validator, collection *Collection) {
+	v.Check(collection.Name != "", "name", "must be provided")
+	v.Check(len(collection.Name) <= 200, "name", "must not be more than 200 bytes long")
+
+	v.Check(len(collection.Description) <= 2_000, "description", "must not be more than 2000 bytes long")
+
+	v.Check(validator.PermittedValue(collection.Visibility, CollectionVisibilities...), "visibility", "must be one of private, unlisted or public")
+}
+
+// TODO: Test at handler level
+func ValidateCollectionItems(v *validator.Validator, items []CollectionItem) {
+	v.Check(items != nil, "items", "must be provided")
+	v.Check(len(items) <= 1_000, "items", "must not contain more than 1000 movies")
+
+	movieIDs := make([]int64, len(items))
+
+	for i, item := range items {
+		movieIDs[i] = item.Movie.ID
+
+		v.Check(item.Movie.ID > 0, fmt.Sprintf("items[%d].movieId", i), "must be provided")
+		v.Check(len(item.Note) <= 1_000, fmt.Sprintf("items[%d].note", i), "must not be more than 1000 bytes long")
+	}
+
+	v.Check(validator.Unique(movieIDs), "items", "must not contain duplicate movies")
+}
+
+func generateShareToken() (string, error) {
+	randomBytes := make([]byte, 16)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes), nil
+}
+
+type CollectionModel struct {
+	DB *sql.DB
+}
+
+func (m CollectionModel) Insert(collection *Collection) error {
+	token, err := generateShareToken()
+	if err != nil {
+		return err
+	}
+
+	collection.ShareToken = token
+
+	query := `
+        INSERT INTO collections (user_id, name, description, visibility, share_token)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at, updated_at, version`
+
+	args := []any{collection.OwnerID, collection.Name, collection.Description, collection.Visibility, collection.ShareToken}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&collection.ID, &collection.CreatedAt, &collection.UpdatedAt, &collection.Version)
+}
+
+func (m CollectionModel) Get(id int64) (*Collection, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+        SELECT id, user_id, name, description, visibility, share_token, created_at, updated_at, version
+        FROM collections
+        WHERE id = $1`
+
+	var collection Collection
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&collection.ID,
+		&collection.OwnerID,
+		&collection.Name,
+		&collection.Description,
+		&collection.Visibility,
+		&collection.ShareToken,
+		&collection.CreatedAt,
+		&collection.UpdatedAt,
+		&collection.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &collection, nil
+}
+
+func (m CollectionModel) Update(collection *Collection) error {
+	query := `
+        UPDATE collections
+        SET name = $1, description = $2, visibility = $3, updated_at = NOW(), version = version + 1
+        WHERE id = $4 AND version = $5
+        RETURNING updated_at, version`
+
+	args := []any{collection.Name, collection.Description, collection.Visibility, collection.ID, collection.Version}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&collection.UpdatedAt, &collection.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m CollectionModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+        DELETE FROM collections
+        WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAll lists collections. When ownerID is zero only public collections
+// are returned, otherwise every collection of that owner.
+func (m CollectionModel) GetAll(ownerID int64, filters Filters) ([]Collection, Metadata, error) {
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, user_id, name, description, visibility, share_token, created_at, updated_at, version
+        FROM collections
+        WHERE (user_id = $1 OR ($1 = 0 AND visibility = 'public'))
+        ORDER BY %s %s, id ASC
+        LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, ownerID, filters.limit(), filters.offsett())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	collections := []Collection{}
+
+	for rows.Next() {
+		var collection Collection
+
+		err := rows.Scan(
+			&totalRecords,
+			&collection.ID,
+			&collection.OwnerID,
+			&collection.Name,
+			&collection.Description,
+			&collection.Visibility,
+			&collection.ShareToken,
+			&collection.CreatedAt,
+			&collection.UpdatedAt,
+			&collection.Version,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		collections = append(collections, collection)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return collections, metadata, nil
+}
+
+func (m CollectionModel) GetItems(collectionID int64) ([]CollectionItem, error) {
+	query := `
+        SELECT movies.id, movies.created_at, movies.title, movies.year, movies.runtime, movies.genres,
+        movies.rating, movies.rating_count, movies.version, movies.poster, collection_items.position, collection_items.note
+        FROM collection_items
+        INNER JOIN movies ON movies.id = collection_items.movie_id
+        WHERE collection_items.collection_id = $1
+        AND movies.deleted_at IS NULL
+        ORDER BY collection_items.position ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []CollectionItem{}
+
+	for rows.Next() {
+		var item CollectionItem
+
+		err := rows.Scan(
+			&item.Movie.ID,
+			&item.Movie.CreatedAt,
+			&item.Movie.Title,
+			&item.Movie.Year,
+			&item.Movie.Runtime,
+			pq.Array(&item.Movie.Genres),
+			&item.Movie.Rating,
+			&item.Movie.RatingCount,
+			&item.Movie.Version,
+			&item.Movie.Poster,
+			&item.Position,
+			&item.Note,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// ReplaceItems stores the items in the given order, replacing the previous
+// contents of the collection. The collection version is checked and bumped
+// in the same transaction, so concurrent edits surface as ErrEditConflict.
+func (m CollectionModel) ReplaceItems(collection *Collection, items []CollectionItem) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+        UPDATE collections
+        SET updated_at = NOW(), version = version + 1
+        WHERE id = $1 AND version = $2
+        RETURNING updated_at, version`
+
+	err = tx.QueryRowContext(ctx, query, collection.ID, collection.Version).Scan(&collection.UpdatedAt, &collection.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM collection_items WHERE collection_id = $1`, collection.ID)
+	if err != nil {
+		return err
+	}
+
+	query = `
+        INSERT INTO collection_items (collection_id, movie_id, position, note)
+        VALUES ($1, $2, $3, $4)`
+
+	for i, item := range items {
+		_, err = tx.ExecContext(ctx, query, collection.ID, item.Movie.ID, i+1, item.Note)
+		if err != nil {
+			switch {
+			case err.Error() == `pq: insert or update on table "collection_items" violates foreign key constraint "collection_items_movie_id_fkey"`:
+				return ErrRecordNotFound
+			default:
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
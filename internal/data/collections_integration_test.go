@@ -0,0 +1,176 @@
+//go:build integration
+// +build integration
+
+package data
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomCollection it's a helper to populate the database
+// with collections. It takes in a pointer of `testing.T`, a pointer
+// of `Models`, the owner and the visibility, and returns a
+// `Collection` created with fake random data.
+func createRandomCollection(t *testing.T, m *Models, ownerID int64, visibility string) Collection {
+	collection := Collection{
+		OwnerID:     ownerID,
+		Name:        gofakeit.Sentence(3),
+		Description: gofakeit.Sentence(10),
+		Visibility:  visibility,
+	}
+
+	err := m.Collections.Insert(&collection)
+
+	require.NoError(t, err)
+
+	require.Equal(t, collection.Version, int32(1))
+	require.NotZero(t, collection.ID)
+	require.NotZero(t, collection.ShareToken)
+	require.NotZero(t, collection.CreatedAt)
+
+	return collection
+}
+
+// collectionModelTestsTeardown it's a helper to truncate the `movies`
+// and `users` tables, and by cascade the collections, during tests.
+func collectionModelTestsTeardown(t *testing.T) {
+	t.Helper()
+
+	movieModelTestsTeardown(t)
+	userModelTestsTeardown(t)
+}
+
+func TestCollectionModelReplaceItems(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully store items in order", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+		collection := createRandomCollection(t, &testModels, user.ID, VisibilityPrivate)
+		movie1 := createRandomMovie(t, &testModels)
+		movie2 := createRandomMovie(t, &testModels)
+
+		err := testModels.Collections.ReplaceItems(&collection, []CollectionItem{
+			{Movie: Movie{ID: movie2.ID}, Note: "First"},
+			{Movie: Movie{ID: movie1.ID}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(2), collection.Version)
+
+		items, err := testModels.Collections.GetItems(collection.ID)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Equal(t, movie2.ID, items[0].Movie.ID)
+		require.Equal(t, "First", items[0].Note)
+		require.Equal(t, 1, items[0].Position)
+		require.Equal(t, movie1.ID, items[1].Movie.ID)
+		require.Equal(t, 2, items[1].Position)
+
+		t.Cleanup(func() {
+			collectionModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrEditConflict' when version does not match", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+		collection := createRandomCollection(t, &testModels, user.ID, VisibilityPrivate)
+
+		collection.Version++
+
+		err := testModels.Collections.ReplaceItems(&collection, []CollectionItem{})
+		require.ErrorIs(t, err, ErrEditConflict)
+
+		t.Cleanup(func() {
+			collectionModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrRecordNotFound' when movie does not exist", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+		collection := createRandomCollection(t, &testModels, user.ID, VisibilityPrivate)
+
+		err := testModels.Collections.ReplaceItems(&collection, []CollectionItem{{Movie: Movie{ID: gofakeit.Int64()}}})
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		t.Cleanup(func() {
+			collectionModelTestsTeardown(t)
+		})
+	})
+}
+
+func TestCollectionModelGetAll(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	owner := createRandomUser(t, &testModels)
+	other := createRandomUser(t, &testModels)
+
+	createRandomCollection(t, &testModels, owner.ID, VisibilityPrivate)
+	createRandomCollection(t, &testModels, owner.ID, VisibilityPublic)
+	createRandomCollection(t, &testModels, other.ID, VisibilityUnlisted)
+
+	filters := Filters{
+		Page:         1,
+		PageSize:     20,
+		Sort:         "id",
+		SortSafeList: CollectionsSortSafeList,
+	}
+
+	t.Run("Returns every collection of the owner", func(t *testing.T) {
+		collections, meta, err := testModels.Collections.GetAll(owner.ID, filters)
+
+		require.NoError(t, err)
+		require.Len(t, collections, 2)
+		require.Equal(t, 2, meta.TotalRecords)
+	})
+
+	t.Run("Returns only public collections without owner", func(t *testing.T) {
+		collections, _, err := testModels.Collections.GetAll(0, filters)
+
+		require.NoError(t, err)
+		require.Len(t, collections, 1)
+		require.Equal(t, VisibilityPublic, collections[0].Visibility)
+	})
+
+	t.Cleanup(func() {
+		collectionModelTestsTeardown(t)
+	})
+}
+
+func TestCollectionModelUpdate(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully update a collection", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+		collection := createRandomCollection(t, &testModels, user.ID, VisibilityPrivate)
+
+		collection.Visibility = VisibilityUnlisted
+
+		err := testModels.Collections.Update(&collection)
+		require.NoError(t, err)
+
+		gotCollection, err := testModels.Collections.Get(collection.ID)
+		require.NoError(t, err)
+		require.Equal(t, VisibilityUnlisted, gotCollection.Visibility)
+		require.Equal(t, int32(2), gotCollection.Version)
+
+		t.Cleanup(func() {
+			collectionModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrEditConflict' when version does not match", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+		collection := createRandomCollection(t, &testModels, user.ID, VisibilityPrivate)
+
+		collection.Version++
+
+		err := testModels.Collections.Update(&collection)
+		require.ErrorIs(t, err, ErrEditConflict)
+
+		t.Cleanup(func() {
+			collectionModelTestsTeardown(t)
+		})
+	})
+}
@@ -0,0 +1,41 @@
+//go:build unit
+// +build unit
+
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectionVisibleTo(t *testing.T) {
+	owner := &User{ID: 1}
+	stranger := &User{ID: 2}
+
+	testCases := []struct {
+		name       string
+		visibility string
+		user       *User
+		token      string
+		expected   bool
+	}{
+		{name: "Public for anonymous user", visibility: VisibilityPublic, user: AnonymousUser, expected: true},
+		{name: "Unlisted without token", visibility: VisibilityUnlisted, user: stranger, expected: false},
+		{name: "Unlisted with wrong token", visibility: VisibilityUnlisted, user: AnonymousUser, token: "wrong", expected: false},
+		{name: "Unlisted with share token", visibility: VisibilityUnlisted, user: AnonymousUser, token: "secret", expected: true},
+		{name: "Private for stranger with share token", visibility: VisibilityPrivate, user: stranger, token: "secret", expected: false},
+		{name: "Private for owner", visibility: VisibilityPrivate, user: owner, expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				collection := &Collection{OwnerID: owner.ID, Visibility: tc.visibility, ShareToken: "secret"}
+
+				require.Equal(t, tc.expected, collection.VisibleTo(tc.user, tc.token))
+			},
+		)
+	}
+}
@@ -0,0 +1,194 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/validator"
+	"github.com/lib/pq"
+)
+
+const (
+	CreditRoleDirector = "director"
+	CreditRoleActor    = "actor"
+	CreditRoleWriter   = "writer"
+)
+
+var (
+	CreditRoles = []string{CreditRoleDirector, CreditRoleActor, CreditRoleWriter}
+
+	ErrPersonNotFound = errors.New("person not found")
+)
+
+type Credit struct {
+	PersonID     int64  `json:"personId"`
+	Name         string `json:"name"`
+	Role         string `json:"role"`
+	Character    string `json:"character,omitempty"`
+	BillingOrder int    `json:"billingOrder,omitempty"`
+}
+
+// TODO: Test at handler level
+func ValidateCredits(v *validator.Validator, credits []Credit) {
+	v.Check(credits != nil, "credits", "must be provided")
+	v.Check(len(credits) <= 500, "credits", "must not contain more than 500 credits")
+
+	seen := make(map[string]bool)
+
+	for i, credit := range credits {
+		key := fmt.Sprintf("credits[%d]", i)
+
+		v.Check(credit.PersonID > 0, key+".personId", "must be provided")
+		v.Check(validator.PermittedValue(credit.Role, CreditRoles...), key+".role", "must be one of director, actor or writer")
+		v.Check(len(credit.Character) <= 500, key+".character", "must not be more than 500 bytes long")
+		v.Check(credit.Character == "" || credit.Role == CreditRoleActor, key+".character", "must only be provided for actors")
+		v.Check(credit.BillingOrder >= 0, key+".billingOrder", "must not be negative")
+
+		id := fmt.Sprintf("%d:%s", credit.PersonID, credit.Role)
+		v.Check(!seen[id], key, "must not repeat the same person and role")
+		seen[id] = true
+	}
+}
+
+type CreditModel struct {
+	DB *sql.DB
+}
+
+func (m CreditModel) GetForMovie(movieID int64) ([]Credit, error) {
+	query := `
+        SELECT movie_credits.person_id, people.name, movie_credits.role, movie_credits.character, movie_credits.billing_order
+        FROM movie_credits
+        INNER JOIN people ON people.id = movie_credits.person_id
+        WHERE movie_credits.movie_id = $1
+        ORDER BY movie_credits.role ASC, movie_credits.billing_order ASC, people.name ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, movieID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	credits := []Credit{}
+
+	for rows.Next() {
+		var credit Credit
+
+		err := rows.Scan(
+			&credit.PersonID,
+			&credit.Name,
+			&credit.Role,
+			&credit.Character,
+			&credit.BillingOrder,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		credits = append(credits, credit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return credits, nil
+}
+
+// GetForMovies returns the credits of several movies at once, keyed by
+// movie ID. Movies without credits are left out of the map.
+func (m CreditModel) GetForMovies(movieIDs []int64) (map[int64][]Credit, error) {
+	query := `
+        SELECT movie_credits.movie_id, movie_credits.person_id, people.name, movie_credits.role, movie_credits.character, movie_credits.billing_order
+        FROM movie_credits
+        INNER JOIN people ON people.id = movie_credits.person_id
+        WHERE movie_credits.movie_id = ANY($1)
+        ORDER BY movie_credits.role ASC, movie_credits.billing_order ASC, people.name ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, pq.Array(movieIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	credits := make(map[int64][]Credit)
+
+	for rows.Next() {
+		var movieID int64
+		var credit Credit
+
+		err := rows.Scan(
+			&movieID,
+			&credit.PersonID,
+			&credit.Name,
+			&credit.Role,
+			&credit.Character,
+			&credit.BillingOrder,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		credits[movieID] = append(credits[movieID], credit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return credits, nil
+}
+
+// ReplaceForMovie swaps all credits of a movie for the given ones in a
+// single transaction, so readers never see a partially updated cast. The
+// movie gets a new version, and ErrEditConflict is returned when it is not
+// at the given version anymore.
+func (m CreditModel) ReplaceForMovie(movie *Movie, credits []Credit) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	err = touchMovie(ctx, tx, movie)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM movie_credits WHERE movie_id = $1`, movie.ID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO movie_credits (movie_id, person_id, role, character, billing_order)
+        VALUES ($1, $2, $3, $4, $5)`
+
+	for _, credit := range credits {
+		_, err = tx.ExecContext(ctx, query, movie.ID, credit.PersonID, credit.Role, credit.Character, credit.BillingOrder)
+		if err != nil {
+			switch {
+			case err.Error() == `pq: insert or update on table "movie_credits" violates foreign key constraint "movie_credits_person_id_fkey"`:
+				return ErrPersonNotFound
+			default:
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,141 @@
+//go:build integration
+// +build integration
+
+package data
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreditModelReplaceForMovie(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully replace the credits of a movie", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		director := createRandomPerson(t, &testModels)
+		actor := createRandomPerson(t, &testModels)
+
+		err := testModels.Credits.ReplaceForMovie(&movie, []Credit{
+			{PersonID: director.ID, Role: CreditRoleDirector},
+			{PersonID: actor.ID, Role: CreditRoleActor, Character: gofakeit.FirstName(), BillingOrder: 1},
+		})
+		require.NoError(t, err)
+
+		credits, err := testModels.Credits.GetForMovie(movie.ID)
+		require.NoError(t, err)
+		require.Len(t, credits, 2)
+
+		err = testModels.Credits.ReplaceForMovie(&movie, []Credit{
+			{PersonID: director.ID, Role: CreditRoleDirector},
+		})
+		require.NoError(t, err)
+
+		credits, err = testModels.Credits.GetForMovie(movie.ID)
+		require.NoError(t, err)
+		require.Len(t, credits, 1)
+		require.Equal(t, director.Name, credits[0].Name)
+
+		got, err := testModels.Movies.Get(movie.ID)
+		require.NoError(t, err)
+		require.Equal(t, int32(3), got.Version)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+			peopleModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrEditConflict' when version does not match", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		movie.Version++
+
+		err := testModels.Credits.ReplaceForMovie(&movie, []Credit{})
+		require.ErrorIs(t, err, ErrEditConflict)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrPersonNotFound' when person does not exist", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+
+		err := testModels.Credits.ReplaceForMovie(&movie, []Credit{
+			{PersonID: gofakeit.Int64(), Role: CreditRoleWriter},
+		})
+		require.ErrorIs(t, err, ErrPersonNotFound)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+}
+
+func TestMovieModelGetAllByDirector(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	directed := createRandomMovie(t, &testModels)
+	acted := createRandomMovie(t, &testModels)
+	person := createRandomPerson(t, &testModels)
+
+	err := testModels.Credits.ReplaceForMovie(&directed, []Credit{{PersonID: person.ID, Role: CreditRoleDirector}})
+	require.NoError(t, err)
+
+	err = testModels.Credits.ReplaceForMovie(&acted, []Credit{{PersonID: person.ID, Role: CreditRoleActor}})
+	require.NoError(t, err)
+
+	movies, meta, err := testModels.Movies.GetAll(MovieQuery{Director: person.Name}, Filters{
+		Page:         1,
+		PageSize:     20,
+		Sort:         "id",
+		SortSafeList: MoviesSortSafeList,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, movies, 1)
+	require.Equal(t, directed.ID, movies[0].ID)
+	require.Equal(t, 1, meta.TotalRecords)
+
+	t.Cleanup(func() {
+		movieModelTestsTeardown(t)
+		peopleModelTestsTeardown(t)
+	})
+}
+
+func TestCreditModelGetForMovies(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully return the credits of several movies", func(t *testing.T) {
+		movie1 := createRandomMovie(t, &testModels)
+		movie2 := createRandomMovie(t, &testModels)
+		movie3 := createRandomMovie(t, &testModels)
+		director := createRandomPerson(t, &testModels)
+		actor := createRandomPerson(t, &testModels)
+
+		err := testModels.Credits.ReplaceForMovie(&movie1, []Credit{
+			{PersonID: director.ID, Role: CreditRoleDirector},
+			{PersonID: actor.ID, Role: CreditRoleActor},
+		})
+		require.NoError(t, err)
+
+		err = testModels.Credits.ReplaceForMovie(&movie2, []Credit{
+			{PersonID: director.ID, Role: CreditRoleDirector},
+		})
+		require.NoError(t, err)
+
+		credits, err := testModels.Credits.GetForMovies([]int64{movie1.ID, movie2.ID, movie3.ID})
+		require.NoError(t, err)
+		require.Len(t, credits, 2)
+		require.Len(t, credits[movie1.ID], 2)
+		require.Len(t, credits[movie2.ID], 1)
+		require.Equal(t, director.Name, credits[movie2.ID][0].Name)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+			peopleModelTestsTeardown(t)
+		})
+	})
+}
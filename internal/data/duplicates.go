@@ -0,0 +1,279 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// duplicateTitleSimilarity is the minimum trigram similarity between two
+// titles for the movies to be reported as likely duplicates.
+const duplicateTitleSimilarity = 0.6
+
+// FindDuplicates returns the movies released in the same year whose title
+// is highly similar to the given one, the closest matches first.
+func (m MovieModel) FindDuplicates(title string, year int32) ([]Movie, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// The % operator can use the trigram index on lower(title), it compares
+	// the similarity to a threshold set for the transaction only.
+	tx, err := m.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, strconv.FormatFloat(duplicateTitleSimilarity, 'f', -1, 64))
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+        SELECT id, created_at, title, year, runtime, genres, rating, rating_count, version, poster
+        FROM movies
+        WHERE lower(title) % lower($1)
+        AND year = $2
+        AND deleted_at IS NULL
+        ORDER BY similarity(lower(title), lower($1)) DESC, id ASC
+        LIMIT 5`
+
+	rows, err := tx.QueryContext(ctx, query, title, year)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	movies := []Movie{}
+
+	for rows.Next() {
+		var movie Movie
+
+		err := rows.Scan(
+			&movie.ID,
+			&movie.CreatedAt,
+			&movie.Title,
+			&movie.Year,
+			&movie.Runtime,
+			pq.Array(&movie.Genres),
+			&movie.Rating,
+			&movie.RatingCount,
+			&movie.Version,
+			&movie.Poster,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		movies = append(movies, movie)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return movies, nil
+}
+
+// Merge folds the source movie into the target one. Ratings, reviews,
+// list entries, collection items, alternate titles and credits move to the
+// target unless it already has an equivalent row, in which case the
+// target's is kept. The source is then deleted, leaving a redirect so its
+// id keeps resolving. The poster of the source is returned along with the
+// target, so its files can be cleaned up.
+func (m MovieModel) Merge(sourceID, targetID, userID int64) (*Movie, *Poster, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer tx.Rollback()
+
+	var sourceVersion, targetVersion int32
+
+	query := `
+        SELECT id, version
+        FROM movies
+        WHERE id = ANY($1) AND deleted_at IS NULL
+        ORDER BY id
+        FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, query, pq.Array([]int64{sourceID, targetID}))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	found := 0
+
+	for rows.Next() {
+		var id int64
+		var version int32
+
+		err = rows.Scan(&id, &version)
+		if err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+
+		switch id {
+		case sourceID:
+			sourceVersion = version
+		case targetID:
+			targetVersion = version
+		}
+
+		found++
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if found != 2 {
+		return nil, nil, ErrRecordNotFound
+	}
+
+	// Each dependent table is keyed by the movie and one other column, rows
+	// the target already has for that column are dropped instead of moved.
+	dependents := []struct {
+		table string
+		key   string
+	}{
+		{"movie_ratings", "user_id"},
+		{"reviews", "user_id"},
+		{"watchlist_items", "user_id"},
+		{"watched_items", "user_id"},
+		{"collection_items", "collection_id"},
+		{"movie_titles", "language"},
+	}
+
+	for _, dependent := range dependents {
+		err = moveMovieDependents(ctx, tx, dependent.table, "t."+dependent.key+" = s."+dependent.key, sourceID, targetID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	err = moveMovieDependents(ctx, tx, "movie_credits", "t.person_id = s.person_id AND t.role = s.role", sourceID, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = insertMovieRevision(ctx, tx, targetID, targetVersion, RevisionActionUpdate, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// This also gives the target its new version.
+	err = refreshMovieRating(ctx, tx, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = insertMovieRevision(ctx, tx, sourceID, sourceVersion, RevisionActionDelete, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query = `
+        UPDATE movie_redirects
+        SET movie_id = $2
+        WHERE movie_id = $1`
+
+	_, err = tx.ExecContext(ctx, query, sourceID, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query = `
+        INSERT INTO movie_redirects (old_id, movie_id)
+        VALUES ($1, $2)`
+
+	_, err = tx.ExecContext(ctx, query, sourceID, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var poster *Poster
+
+	err = tx.QueryRowContext(ctx, `DELETE FROM movies WHERE id = $1 RETURNING poster`, sourceID).Scan(&poster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = recordMovieEvent(ctx, tx, EventMovieDeleted, sourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = recordMovieEvent(ctx, tx, EventMovieUpdated, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	target, err := getMovie(ctx, tx, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &target, poster, nil
+}
+
+// moveMovieDependents re-points the rows of table from the source movie to
+// the target one, skipping those matching a target row on the conflict
+// condition, which are left behind to be removed with the source movie.
+func moveMovieDependents(ctx context.Context, tx *sql.Tx, table, conflict string, sourceID, targetID int64) error {
+	query := `
+        UPDATE ` + table + ` AS s
+        SET movie_id = $2
+        WHERE s.movie_id = $1
+        AND NOT EXISTS (
+            SELECT 1
+            FROM ` + table + ` AS t
+            WHERE t.movie_id = $2 AND ` + conflict + `
+        )`
+
+	_, err := tx.ExecContext(ctx, query, sourceID, targetID)
+	return err
+}
+
+// ResolveRedirect returns the id of the movie an old, merged, id points to.
+func (m MovieModel) ResolveRedirect(id int64) (int64, error) {
+	query := `
+        SELECT movie_id
+        FROM movie_redirects
+        WHERE old_id = $1`
+
+	var movieID int64
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(&movieID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, ErrRecordNotFound
+		default:
+			return 0, err
+		}
+	}
+
+	return movieID, nil
+}
@@ -0,0 +1,138 @@
+//go:build integration
+// +build integration
+
+package data
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+)
+
+// duplicateModelTestsTeardown it's a helper to truncate the `movies`
+// and `users` tables, and by cascade their dependents, during tests.
+func duplicateModelTestsTeardown(t *testing.T) {
+	t.Helper()
+
+	movieModelTestsTeardown(t)
+	userModelTestsTeardown(t)
+}
+
+func TestMovieModelFindDuplicates(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Return movies with a similar title and the same year", func(t *testing.T) {
+		movie := &Movie{Title: "The Matrix", Year: 1999, Runtime: 136, Genres: []string{"action"}}
+		require.NoError(t, testModels.Movies.Insert(movie))
+
+		other := &Movie{Title: "The Matrix", Year: 2021, Runtime: 148, Genres: []string{"action"}}
+		require.NoError(t, testModels.Movies.Insert(other))
+
+		candidates, err := testModels.Movies.FindDuplicates("the matrix ", 1999)
+		require.NoError(t, err)
+		require.Len(t, candidates, 1)
+		require.Equal(t, movie.ID, candidates[0].ID)
+
+		candidates, err = testModels.Movies.FindDuplicates("Casablanca", 1999)
+		require.NoError(t, err)
+		require.Empty(t, candidates)
+
+		t.Cleanup(func() {
+			duplicateModelTestsTeardown(t)
+		})
+	})
+}
+
+func TestMovieModelMerge(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully fold a movie into another", func(t *testing.T) {
+		source := createRandomMovie(t, &testModels)
+		target := createRandomMovie(t, &testModels)
+
+		user1 := createRandomUser(t, &testModels)
+		user2 := createRandomUser(t, &testModels)
+
+		require.NoError(t, testModels.Ratings.Upsert(&Rating{MovieID: source.ID, UserID: user1.ID, Value: 2}))
+		require.NoError(t, testModels.Ratings.Upsert(&Rating{MovieID: source.ID, UserID: user2.ID, Value: 4}))
+		require.NoError(t, testModels.Ratings.Upsert(&Rating{MovieID: target.ID, UserID: user1.ID, Value: 8}))
+
+		_, err := testModels.Watchlist.Add(user2.ID, source.ID)
+		require.NoError(t, err)
+
+		sourcePoster := &Poster{
+			Original: "http://localhost/media/posters/1/a/original.jpg",
+			Keys:     []string{"posters/1/a/original.jpg"},
+		}
+
+		_, err = testModels.Movies.SetPoster(&source, sourcePoster, 0)
+		require.NoError(t, err)
+
+		merged, poster, err := testModels.Movies.Merge(source.ID, target.ID, user1.ID)
+		require.NoError(t, err)
+		require.Equal(t, sourcePoster, poster, "the poster of the source is returned for cleanup")
+		require.Equal(t, target.ID, merged.ID)
+		require.Equal(t, int32(2), merged.RatingCount)
+		require.Equal(t, 6.0, merged.Rating)
+		require.Equal(t, target.Version+2, merged.Version, "rating the target and merging into it both give it a new version")
+
+		revision, err := testModels.Revisions.Get(target.ID, merged.Version-1)
+		require.NoError(t, err)
+		require.Equal(t, RevisionActionUpdate, revision.Action)
+
+		rating, err := testModels.Ratings.GetForUser(target.ID, user1.ID)
+		require.NoError(t, err)
+		require.Equal(t, 8, rating.Value)
+
+		items, _, err := testModels.Watchlist.GetAll(user2.ID, Filters{Page: 1, PageSize: 20, Sort: "added_at", SortSafeList: WatchlistSortSafeList})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		require.Equal(t, target.ID, items[0].Movie.ID)
+
+		_, err = testModels.Movies.Get(source.ID)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		movieID, err := testModels.Movies.ResolveRedirect(source.ID)
+		require.NoError(t, err)
+		require.Equal(t, target.ID, movieID)
+
+		t.Cleanup(func() {
+			duplicateModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Re-point earlier redirects to the new target", func(t *testing.T) {
+		first := createRandomMovie(t, &testModels)
+		second := createRandomMovie(t, &testModels)
+		third := createRandomMovie(t, &testModels)
+
+		_, _, err := testModels.Movies.Merge(first.ID, second.ID, 0)
+		require.NoError(t, err)
+
+		_, _, err = testModels.Movies.Merge(second.ID, third.ID, 0)
+		require.NoError(t, err)
+
+		movieID, err := testModels.Movies.ResolveRedirect(first.ID)
+		require.NoError(t, err)
+		require.Equal(t, third.ID, movieID)
+
+		t.Cleanup(func() {
+			duplicateModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrRecordNotFound' when a movie does not exist", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+
+		_, _, err := testModels.Movies.Merge(movie.ID, gofakeit.Int64(), 0)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		_, err = testModels.Movies.ResolveRedirect(movie.ID)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		t.Cleanup(func() {
+			duplicateModelTestsTeardown(t)
+		})
+	})
+}
@@ -0,0 +1,143 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// MovieEventsChannel is the Postgres notification channel on which the ID of
+// every new movie event is sent once committed, so that every API instance
+// learns about it.
+const MovieEventsChannel = "movie_events"
+
+// MovieEvent is an entry of the movie change log, one of the movie events
+// with its payload as sent to webhooks. Its ID is the position assigned to
+// it when committed, so that the log reads in commit order: an event is
+// never visible before one with a lower ID.
+type MovieEvent struct {
+	ID        int64           `json:"id"`
+	Event     string          `json:"event"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+type MovieEventModel struct {
+	DB *sql.DB
+}
+
+// recordMovieEvent records a change of the movie within the transaction
+// making it, so that it is published if and only if the change is committed:
+// the event is queued for the subscribed webhooks and appended to the log,
+// where it gets its ID, and is notified on MovieEventsChannel, once
+// committed. The payload is the movie as stored, or only its ID once
+// deleted.
+func recordMovieEvent(ctx context.Context, tx *sql.Tx, event string, movieID int64) error {
+	var payload any = map[string]int64{"id": movieID}
+
+	if event != EventMovieDeleted {
+		movie, err := getMovie(ctx, tx, movieID)
+		if err != nil {
+			return err
+		}
+
+		payload = movie
+	}
+
+	js, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	err = enqueueDeliveries(ctx, tx, event, js)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO movie_events (event, payload)
+        VALUES ($1, $2)`
+
+	_, err = tx.ExecContext(ctx, query, event, js)
+	return err
+}
+
+// Trim removes the events logged before the last size ones, returning how
+// many were removed.
+func (m MovieEventModel) Trim(size int) (int64, error) {
+	query := `
+        DELETE FROM movie_events
+        WHERE position <= (SELECT MAX(position) FROM movie_events) - $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, size)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetAfter returns, in commit order, up to limit events logged after the one
+// with the given ID that are still in the log.
+func (m MovieEventModel) GetAfter(id int64, limit int) ([]MovieEvent, error) {
+	query := `
+        SELECT position, event, payload, created_at
+        FROM movie_events
+        WHERE position > $1
+        ORDER BY position ASC
+        LIMIT $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	movieEvents := []MovieEvent{}
+
+	for rows.Next() {
+		var movieEvent MovieEvent
+
+		err := rows.Scan(
+			&movieEvent.ID,
+			&movieEvent.Event,
+			&movieEvent.Payload,
+			&movieEvent.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		movieEvents = append(movieEvents, movieEvent)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return movieEvents, nil
+}
+
+// LatestID returns the ID of the last logged event, or zero when the log is
+// empty.
+func (m MovieEventModel) LatestID() (int64, error) {
+	query := `
+        SELECT COALESCE(MAX(position), 0)
+        FROM movie_events`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var id int64
+
+	err := m.DB.QueryRowContext(ctx, query).Scan(&id)
+	return id, err
+}
@@ -0,0 +1,140 @@
+//go:build integration
+// +build integration
+
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// movieEventModelTestsTeardown it's a helper to truncate the
+// `movie_events` table in the database during tests.
+func movieEventModelTestsTeardown(t *testing.T) {
+	t.Helper()
+
+	query := `TRUNCATE TABLE movie_events RESTART IDENTITY`
+
+	_, err := testDB.Exec(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMovieEventModel(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Log the movie changes with them, read them back and trim them", func(t *testing.T) {
+		latest, err := testModels.MovieEvents.LatestID()
+		require.NoError(t, err)
+
+		movie := createRandomMovie(t, &testModels)
+
+		movie.Title = "Renamed"
+		require.NoError(t, testModels.Movies.Update(&movie, 0))
+		require.NoError(t, testModels.Movies.Delete(movie.ID, 0, 0))
+
+		movieEvents, err := testModels.MovieEvents.GetAfter(latest, 10)
+		require.NoError(t, err)
+		require.Len(t, movieEvents, 3)
+		require.Equal(t, EventMovieCreated, movieEvents[0].Event)
+		require.Equal(t, EventMovieUpdated, movieEvents[1].Event)
+		require.Equal(t, EventMovieDeleted, movieEvents[2].Event)
+
+		var payload struct {
+			ID    int64  `json:"id"`
+			Title string `json:"title"`
+		}
+
+		require.NoError(t, json.Unmarshal(movieEvents[1].Payload, &payload))
+		require.Equal(t, movie.ID, payload.ID)
+		require.Equal(t, "Renamed", payload.Title)
+
+		require.JSONEq(t, fmt.Sprintf(`{"id":%d}`, movie.ID), string(movieEvents[2].Payload))
+
+		next, err := testModels.MovieEvents.GetAfter(movieEvents[0].ID, 1)
+		require.NoError(t, err)
+		require.Len(t, next, 1)
+		require.Equal(t, movieEvents[1].ID, next[0].ID)
+
+		_, err = testModels.MovieEvents.Trim(1)
+		require.NoError(t, err)
+
+		latest, err = testModels.MovieEvents.LatestID()
+		require.NoError(t, err)
+		require.Equal(t, movieEvents[2].ID, latest)
+
+		kept, err := testModels.MovieEvents.GetAfter(0, 10)
+		require.NoError(t, err)
+		require.Len(t, kept, 1)
+
+		t.Cleanup(func() {
+			movieEventModelTestsTeardown(t)
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Read the events in commit order", func(t *testing.T) {
+		latest, err := testModels.MovieEvents.LatestID()
+		require.NoError(t, err)
+
+		ctx := context.Background()
+
+		first, err := testDB.BeginTx(ctx, nil)
+		require.NoError(t, err)
+
+		defer first.Rollback()
+
+		require.NoError(t, recordMovieEvent(ctx, first, EventMovieDeleted, 1))
+
+		second, err := testDB.BeginTx(ctx, nil)
+		require.NoError(t, err)
+
+		defer second.Rollback()
+
+		require.NoError(t, recordMovieEvent(ctx, second, EventMovieDeleted, 2))
+
+		require.NoError(t, second.Commit())
+
+		movieEvents, err := testModels.MovieEvents.GetAfter(latest, 10)
+		require.NoError(t, err)
+		require.Len(t, movieEvents, 1, "the event of a pending transaction is not visible")
+
+		require.NoError(t, first.Commit())
+
+		movieEvents, err = testModels.MovieEvents.GetAfter(movieEvents[0].ID, 10)
+		require.NoError(t, err)
+		require.Len(t, movieEvents, 1, "the event committed last comes after")
+		require.JSONEq(t, `{"id":1}`, string(movieEvents[0].Payload))
+
+		t.Cleanup(func() {
+			movieEventModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Discard the events of a rolled back change", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+
+		latest, err := testModels.MovieEvents.LatestID()
+		require.NoError(t, err)
+
+		stale := movie
+		stale.Version--
+
+		err = testModels.Movies.Update(&stale, 0)
+		require.ErrorIs(t, err, ErrEditConflict)
+
+		movieEvents, err := testModels.MovieEvents.GetAfter(latest, 10)
+		require.NoError(t, err)
+		require.Empty(t, movieEvents)
+
+		t.Cleanup(func() {
+			movieEventModelTestsTeardown(t)
+			movieModelTestsTeardown(t)
+		})
+	})
+}
@@ -49,6 +49,20 @@ func ValidateFilters(v *validator.Validator, f Filters) {
 	v.Check(validator.PermittedValue(f.Sort, f.SortSafeList...), "sort", "invalid sort value")
 }
 
+// ValidateFieldset checks a list of field or relation names, such as the
+// fields and include query string parameters, against its safe list.
+// TODO: Test at handler level
+func ValidateFieldset(v *validator.Validator, key string, values []string, safeList []string) {
+	v.Check(validator.Unique(values), key, "must not contain duplicate values")
+
+	for _, value := range values {
+		if !validator.PermittedValue(value, safeList...) {
+			v.AddError(key, "must only contain "+strings.Join(safeList, ", "))
+			break
+		}
+	}
+}
+
 type Metadata struct {
 	CurrentPage  int `json:"currentPage,omitempty"`
 	PageSize     int `json:"pageSize,omitempty"`
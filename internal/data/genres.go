@@ -0,0 +1,530 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/validator"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrDuplicateGenre      = errors.New("duplicate genre")
+	ErrDuplicateGenreAlias = errors.New("duplicate genre alias")
+	ErrGenreInUse          = errors.New("genre in use")
+)
+
+// genreCatalogueTTL bounds how long a cached catalogue is used, so that
+// changes made through another instance are eventually picked up.
+const genreCatalogueTTL = time.Minute
+
+var GenreSlugRX = regexp.MustCompile("^[a-z0-9]+(?:-[a-z0-9]+)*$")
+
+type Genre struct {
+	ID         int64     `json:"id"`
+	CreatedAt  time.Time `json:"-"`
+	Slug       string    `json:"slug"`
+	Name       string    `json:"name"`
+	Aliases    []string  `json:"aliases"`
+	MovieCount int       `json:"movieCount"`
+	Version    int32     `json:"version"`
+}
+
+// Genres is the catalogue of managed genres, used to resolve free-text
+// genre names and aliases to their canonical slugs.
+type Genres []Genre
+
+// Lookup returns the canonical slug for a genre slug, name or alias. The
+// comparison is case-insensitive and ignores surrounding whitespace.
+func (g Genres) Lookup(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	for i := range g {
+		if name == g[i].Slug || name == strings.ToLower(g[i].Name) {
+			return g[i].Slug, true
+		}
+
+		for _, alias := range g[i].Aliases {
+			if name == alias {
+				return g[i].Slug, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// Normalize maps every known genre to its canonical slug, leaving unknown
+// values untouched so they can be reported by ValidateMovie.
+func (g Genres) Normalize(names []string) []string {
+	if names == nil {
+		return nil
+	}
+
+	normalized := make([]string, len(names))
+
+	for i, name := range names {
+		if slug, ok := g.Lookup(name); ok {
+			normalized[i] = slug
+			continue
+		}
+
+		normalized[i] = name
+	}
+
+	return normalized
+}
+
+// Include returns true if the slug belongs to the catalogue.
+func (g Genres) Include(slug string) bool {
+	for i := range g {
+		if slug == g[i].Slug {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TODO: Test at handler level
+func ValidateGenre(v *validator.Validator, genre *Genre) {
+	v.Check(genre.Slug != "", "slug", "must be provided")
+	v.Check(len(genre.Slug) <= 50, "slug", "must not be more than 50 bytes long")
+	v.Check(validator.Matches(genre.Slug, GenreSlugRX), "slug", "must contain only lowercase letters, digits and hyphens")
+
+	v.Check(genre.Name != "", "name", "must be provided")
+	v.Check(len(genre.Name) <= 100, "name", "must not be more than 100 bytes long")
+
+	v.Check(genre.Aliases != nil, "aliases", "must be provided")
+	v.Check(len(genre.Aliases) <= 20, "aliases", "must not contain more than 20 aliases")
+	v.Check(validator.Unique(genre.Aliases), "aliases", "must not contain duplicate values")
+	v.Check(!validator.PermittedValue(genre.Slug, genre.Aliases...), "aliases", "must not contain the genre slug")
+
+	for _, alias := range genre.Aliases {
+		v.Check(alias != "", "aliases", "must not contain empty values")
+		v.Check(alias == strings.ToLower(strings.TrimSpace(alias)), "aliases", "must be lowercase without surrounding spaces")
+	}
+}
+
+// genreCatalogue caches the catalogue shared by the copies of a GenreModel.
+type genreCatalogue struct {
+	mu      sync.Mutex
+	genres  Genres
+	expires time.Time
+}
+
+type GenreModel struct {
+	DB        *sql.DB
+	catalogue *genreCatalogue
+}
+
+// lockGenres serializes the genre writes for the rest of the transaction,
+// so that checkGenreAliases sees every other committed genre. Reads are
+// not blocked.
+func lockGenres(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `LOCK TABLE genres IN SHARE ROW EXCLUSIVE MODE`)
+	return err
+}
+
+// checkGenreAliases returns ErrDuplicateGenreAlias when an alias of the
+// genre is the slug, name or alias of another genre, or the other way
+// around, as Genres.Lookup could then resolve it to either of them.
+func checkGenreAliases(ctx context.Context, tx *sql.Tx, genre *Genre) error {
+	query := `
+        SELECT EXISTS (
+            SELECT 1
+            FROM genres
+            WHERE id <> $1
+            AND (aliases && $2 OR slug = ANY($2) OR lower(name) = ANY($2) OR $3 = ANY(aliases) OR lower($4) = ANY(aliases))
+        )`
+
+	args := []any{genre.ID, pq.Array(genre.Aliases), genre.Slug, genre.Name}
+
+	var exists bool
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return ErrDuplicateGenreAlias
+	}
+
+	return nil
+}
+
+func (m GenreModel) Insert(genre *Genre) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	err = lockGenres(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	err = checkGenreAliases(ctx, tx, genre)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO genres (slug, name, aliases)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at, version`
+
+	args := []any{genre.Slug, genre.Name, pq.Array(genre.Aliases)}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&genre.ID, &genre.CreatedAt, &genre.Version)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "genres_slug_key"`:
+			return ErrDuplicateGenre
+		default:
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	m.invalidateCatalogue()
+
+	return nil
+}
+
+func (m GenreModel) Get(id int64) (*Genre, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+        SELECT genres.id, genres.created_at, genres.slug, genres.name, genres.aliases, genres.version,
+        (SELECT count(*) FROM movies WHERE movies.genres @> ARRAY[genres.slug] AND movies.deleted_at IS NULL)
+        FROM genres
+        WHERE genres.id = $1`
+
+	var genre Genre
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&genre.ID,
+		&genre.CreatedAt,
+		&genre.Slug,
+		&genre.Name,
+		pq.Array(&genre.Aliases),
+		&genre.Version,
+		&genre.MovieCount,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &genre, nil
+}
+
+// Catalogue returns the slugs, names and aliases of every genre, which is
+// all that resolving genre names needs. The result is cached and shared, so
+// it must not be modified.
+func (m GenreModel) Catalogue() (Genres, error) {
+	if m.catalogue == nil {
+		return m.getCatalogue()
+	}
+
+	m.catalogue.mu.Lock()
+	defer m.catalogue.mu.Unlock()
+
+	if m.catalogue.genres != nil && time.Now().Before(m.catalogue.expires) {
+		return m.catalogue.genres, nil
+	}
+
+	genres, err := m.getCatalogue()
+	if err != nil {
+		return nil, err
+	}
+
+	m.catalogue.genres = genres
+	m.catalogue.expires = time.Now().Add(genreCatalogueTTL)
+
+	return genres, nil
+}
+
+func (m GenreModel) getCatalogue() (Genres, error) {
+	query := `
+        SELECT slug, name, aliases
+        FROM genres
+        ORDER BY slug ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	genres := Genres{}
+
+	for rows.Next() {
+		var genre Genre
+
+		err := rows.Scan(&genre.Slug, &genre.Name, pq.Array(&genre.Aliases))
+		if err != nil {
+			return nil, err
+		}
+
+		genres = append(genres, genre)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return genres, nil
+}
+
+// invalidateCatalogue drops the cached catalogue after a genre change.
+func (m GenreModel) invalidateCatalogue() {
+	if m.catalogue == nil {
+		return
+	}
+
+	m.catalogue.mu.Lock()
+	defer m.catalogue.mu.Unlock()
+
+	m.catalogue.genres = nil
+}
+
+// GetAll returns every genre with the number of movies using it.
+func (m GenreModel) GetAll() (Genres, error) {
+	query := `
+        SELECT genres.id, genres.created_at, genres.slug, genres.name, genres.aliases, genres.version,
+        (SELECT count(*) FROM movies WHERE movies.genres @> ARRAY[genres.slug] AND movies.deleted_at IS NULL)
+        FROM genres
+        ORDER BY genres.slug ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	genres := Genres{}
+
+	for rows.Next() {
+		var genre Genre
+
+		err := rows.Scan(
+			&genre.ID,
+			&genre.CreatedAt,
+			&genre.Slug,
+			&genre.Name,
+			pq.Array(&genre.Aliases),
+			&genre.Version,
+			&genre.MovieCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		genres = append(genres, genre)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return genres, nil
+}
+
+// Update saves the genre and, when the slug changed, renames it in every
+// movie that references the previous slug within the same transaction. The
+// renamed movies get a new version, with their previous state and the
+// acting user recorded in the revision history, and are reported updated.
+func (m GenreModel) Update(genre *Genre, userID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	err = lockGenres(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	var previousSlug string
+
+	query := `
+        SELECT slug
+        FROM genres
+        WHERE id = $1 AND version = $2
+        FOR UPDATE`
+
+	err = tx.QueryRowContext(ctx, query, genre.ID, genre.Version).Scan(&previousSlug)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	err = checkGenreAliases(ctx, tx, genre)
+	if err != nil {
+		return err
+	}
+
+	query = `
+        UPDATE genres
+        SET slug = $1, name = $2, aliases = $3, version = version + 1
+        WHERE id = $4 AND version = $5
+        RETURNING version`
+
+	args := []any{genre.Slug, genre.Name, pq.Array(genre.Aliases), genre.ID, genre.Version}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&genre.Version)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "genres_slug_key"`:
+			return ErrDuplicateGenre
+		default:
+			return err
+		}
+	}
+
+	if previousSlug != genre.Slug {
+		query = `
+            INSERT INTO movie_revisions (movie_id, version, title, year, runtime, genres, action, user_id)
+            SELECT id, version, title, year, runtime, genres, $2, NULLIF($3, 0)
+            FROM movies
+            WHERE genres @> ARRAY[$1]`
+
+		_, err = tx.ExecContext(ctx, query, previousSlug, RevisionActionUpdate, userID)
+		if err != nil {
+			return err
+		}
+
+		query = `
+            UPDATE movies
+            SET genres = array_replace(genres, $1, $2), updated_at = NOW(), version = version + 1
+            WHERE genres @> ARRAY[$1]
+            RETURNING id, deleted_at IS NULL`
+
+		rows, err := tx.QueryContext(ctx, query, previousSlug, genre.Slug)
+		if err != nil {
+			return err
+		}
+
+		var renamed []int64
+
+		for rows.Next() {
+			var id int64
+			var live bool
+
+			err = rows.Scan(&id, &live)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+
+			// Trashed movies were already reported deleted.
+			if live {
+				renamed = append(renamed, id)
+			}
+		}
+
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		for _, id := range renamed {
+			err = recordMovieEvent(ctx, tx, EventMovieUpdated, id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	m.invalidateCatalogue()
+
+	return nil
+}
+
+// Delete removes a genre, refusing with ErrGenreInUse while any movie
+// still references it.
+func (m GenreModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+        DELETE FROM genres
+        WHERE id = $1
+        AND NOT EXISTS (SELECT 1 FROM movies WHERE movies.genres @> ARRAY[genres.slug])`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		_, err := m.Get(id)
+		if err != nil {
+			return err
+		}
+
+		return ErrGenreInUse
+	}
+
+	m.invalidateCatalogue()
+
+	return nil
+}
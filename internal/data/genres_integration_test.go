@@ -0,0 +1,191 @@
+//go:build integration
+// +build integration
+
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomGenre it's a helper to populate the database
+// with genres. It takes in a pointer of `testing.T` and
+// a pointer of `Models`, and returns a `Genre` created
+// with fake random data. The genre is deleted when the
+// test finishes, so the catalogue seeded by the migrations
+// is left untouched.
+func createRandomGenre(t *testing.T, m *Models) Genre {
+	genre := Genre{
+		Slug:    strings.ToLower(gofakeit.LetterN(12)),
+		Name:    gofakeit.Word(),
+		Aliases: []string{strings.ToLower(gofakeit.LetterN(12))},
+	}
+
+	err := m.Genres.Insert(&genre)
+
+	require.NoError(t, err)
+
+	require.Equal(t, genre.Version, int32(1))
+	require.NotZero(t, genre.ID)
+	require.NotZero(t, genre.CreatedAt)
+
+	t.Cleanup(func() {
+		_, err := testDB.Exec(`DELETE FROM genres WHERE id = $1`, genre.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	return genre
+}
+
+func TestGenreModelInsert(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully insert a genre", func(t *testing.T) {
+		createRandomGenre(t, &testModels)
+	})
+
+	t.Run("'ErrDuplicateGenre' when slug already exists", func(t *testing.T) {
+		genre := createRandomGenre(t, &testModels)
+
+		duplicate := Genre{Slug: genre.Slug, Name: gofakeit.Word(), Aliases: []string{}}
+
+		err := testModels.Genres.Insert(&duplicate)
+
+		require.ErrorIs(t, err, ErrDuplicateGenre)
+	})
+
+	t.Run("'ErrDuplicateGenreAlias' when an alias belongs to another genre", func(t *testing.T) {
+		genre := createRandomGenre(t, &testModels)
+
+		testCases := []Genre{
+			{Slug: strings.ToLower(gofakeit.LetterN(12)), Name: gofakeit.Word(), Aliases: genre.Aliases},
+			{Slug: strings.ToLower(gofakeit.LetterN(12)), Name: gofakeit.Word(), Aliases: []string{genre.Slug}},
+			{Slug: genre.Aliases[0], Name: gofakeit.Word(), Aliases: []string{}},
+		}
+
+		for _, duplicate := range testCases {
+			err := testModels.Genres.Insert(&duplicate)
+
+			require.ErrorIs(t, err, ErrDuplicateGenreAlias)
+		}
+	})
+}
+
+func TestGenreModelGetAll(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	genre := createRandomGenre(t, &testModels)
+
+	genres, err := testModels.Genres.GetAll()
+
+	require.NoError(t, err)
+	require.True(t, genres.Include(genre.Slug))
+
+	slug, found := genres.Lookup(genre.Aliases[0])
+
+	require.True(t, found)
+	require.Equal(t, genre.Slug, slug)
+}
+
+func TestGenreModelCatalogue(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	genres, err := testModels.Genres.Catalogue()
+	require.NoError(t, err)
+
+	genre := createRandomGenre(t, &testModels)
+
+	require.False(t, genres.Include(genre.Slug))
+
+	genres, err = testModels.Genres.Catalogue()
+	require.NoError(t, err)
+	require.True(t, genres.Include(genre.Slug), "the cached catalogue is dropped when a genre changes")
+}
+
+func TestGenreModelUpdate(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully rename a genre used by movies", func(t *testing.T) {
+		genre := createRandomGenre(t, &testModels)
+
+		movie := createRandomMovie(t, &testModels)
+		movie.Genres = []string{genre.Slug}
+
+		err := testModels.Movies.Update(&movie, 0)
+		require.NoError(t, err)
+
+		genre.Slug = strings.ToLower(gofakeit.LetterN(12))
+
+		err = testModels.Genres.Update(&genre, 0)
+		require.NoError(t, err)
+		require.Equal(t, int32(2), genre.Version)
+
+		updatedMovie, err := testModels.Movies.Get(movie.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{genre.Slug}, updatedMovie.Genres)
+		require.Equal(t, movie.Version+1, updatedMovie.Version)
+
+		revision, err := testModels.Revisions.Get(movie.ID, movie.Version)
+		require.NoError(t, err)
+		require.Equal(t, RevisionActionUpdate, revision.Action)
+
+		gotGenre, err := testModels.Genres.Get(genre.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, gotGenre.MovieCount)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrEditConflict' when version does not match", func(t *testing.T) {
+		genre := createRandomGenre(t, &testModels)
+		genre.Version++
+
+		err := testModels.Genres.Update(&genre, 0)
+
+		require.ErrorIs(t, err, ErrEditConflict)
+	})
+}
+
+func TestGenreModelDelete(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully delete a genre", func(t *testing.T) {
+		genre := createRandomGenre(t, &testModels)
+
+		err := testModels.Genres.Delete(genre.ID)
+		require.NoError(t, err)
+
+		_, err = testModels.Genres.Get(genre.ID)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	})
+
+	t.Run("'ErrGenreInUse' when a movie references the genre", func(t *testing.T) {
+		genre := createRandomGenre(t, &testModels)
+
+		movie := createRandomMovie(t, &testModels)
+		movie.Genres = []string{genre.Slug}
+
+		err := testModels.Movies.Update(&movie, 0)
+		require.NoError(t, err)
+
+		err = testModels.Genres.Delete(genre.ID)
+		require.ErrorIs(t, err, ErrGenreInUse)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrRecordNotFound' when given 'ID' does not exist", func(t *testing.T) {
+		err := testModels.Genres.Delete(gofakeit.Int64())
+
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,61 @@
+//go:build unit
+// +build unit
+
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testGenres = Genres{
+	{Slug: "comedy", Name: "Comedy", Aliases: []string{}},
+	{Slug: "sci-fi", Name: "Science Fiction", Aliases: []string{"science fiction", "scifi"}},
+}
+
+func TestGenresLookup(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected string
+		found    bool
+	}{
+		{name: "Slug", value: "comedy", expected: "comedy", found: true},
+		{name: "Name with different case", value: "Science Fiction", expected: "sci-fi", found: true},
+		{name: "Slug with different case", value: "Sci-Fi", expected: "sci-fi", found: true},
+		{name: "Alias", value: "scifi", expected: "sci-fi", found: true},
+		{name: "Alias with surrounding spaces", value: "  science fiction ", expected: "sci-fi", found: true},
+		{name: "Unknown genre", value: "horror", expected: "", found: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				slug, found := testGenres.Lookup(tc.value)
+
+				require.Equal(t, tc.found, found)
+				require.Equal(t, tc.expected, slug)
+			},
+		)
+	}
+}
+
+func TestGenresNormalize(t *testing.T) {
+	t.Run("Maps known genres and keeps unknown ones", func(t *testing.T) {
+		normalized := testGenres.Normalize([]string{"Comedy", "science fiction", "Horror"})
+
+		require.Equal(t, []string{"comedy", "sci-fi", "Horror"}, normalized)
+	})
+
+	t.Run("Keeps nil slices as nil", func(t *testing.T) {
+		require.Nil(t, testGenres.Normalize(nil))
+	})
+}
+
+func TestGenresInclude(t *testing.T) {
+	require.True(t, testGenres.Include("sci-fi"))
+	require.False(t, testGenres.Include("scifi"))
+	require.False(t, testGenres.Include("horror"))
+}
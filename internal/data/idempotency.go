@@ -0,0 +1,178 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/validator"
+)
+
+// IdempotencyKey records the response to a request sent with an
+// Idempotency-Key header, so that retries of the request get the same
+// response instead of repeating its effects. The key is scoped to its owner,
+// the user or the client who sent the request.
+type IdempotencyKey struct {
+	Owner       string
+	Key         string
+	RequestHash []byte
+	// Status is zero while the request is being processed.
+	Status int
+	// Failed is set when the request was processed but its response
+	// could not be stored, the key then stays taken until it expires.
+	Failed    bool
+	Header    map[string][]string
+	Body      []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// InFlight reports whether the request the key was reserved for is still
+// being processed.
+func (k *IdempotencyKey) InFlight() bool {
+	return k.Status == 0 && !k.Failed
+}
+
+// TODO: Test at handler level
+func ValidateIdempotencyKey(v *validator.Validator, key string) {
+	v.Check(key != "", "Idempotency-Key", "must not be empty")
+	v.Check(len(key) <= 255, "Idempotency-Key", "must not be more than 255 bytes long")
+}
+
+type IdempotencyKeyModel struct {
+	DB *sql.DB
+}
+
+// Reserve claims the key for a request with the given hash, for ttl. It
+// returns nil when the key was free, had expired, or was left in flight for
+// longer than lockTimeout by an identical request, and the record holding
+// the key otherwise. Failed keys are only freed once expired.
+func (m IdempotencyKeyModel) Reserve(owner, key string, requestHash []byte, ttl, lockTimeout time.Duration) (*IdempotencyKey, error) {
+	query := `
+        INSERT INTO idempotency_keys (owner, key, request_hash, expires_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (owner, key) DO UPDATE
+        SET request_hash = EXCLUDED.request_hash, status = NULL, header = NULL, body = NULL,
+            failed = false, created_at = NOW(), expires_at = EXCLUDED.expires_at
+        WHERE idempotency_keys.expires_at < NOW()
+        OR (idempotency_keys.status IS NULL
+            AND NOT idempotency_keys.failed
+            AND idempotency_keys.created_at < $5
+            AND idempotency_keys.request_hash = EXCLUDED.request_hash)
+        RETURNING owner`
+
+	now := time.Now()
+	args := []any{owner, key, requestHash, now.Add(ttl), now.Add(-lockTimeout)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&owner)
+	if err == nil {
+		return nil, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	query = `
+        SELECT request_hash, COALESCE(status, 0), failed, header, body, created_at, expires_at
+        FROM idempotency_keys
+        WHERE owner = $1 AND key = $2`
+
+	record := IdempotencyKey{Owner: owner, Key: key}
+
+	var header []byte
+
+	err = m.DB.QueryRowContext(ctx, query, owner, key).Scan(
+		&record.RequestHash,
+		&record.Status,
+		&record.Failed,
+		&header,
+		&record.Body,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if header != nil {
+		err = json.Unmarshal(header, &record.Header)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &record, nil
+}
+
+// Complete stores the response to the request the key was reserved for.
+func (m IdempotencyKeyModel) Complete(record *IdempotencyKey) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        UPDATE idempotency_keys
+        SET status = $1, header = $2, body = $3
+        WHERE owner = $4 AND key = $5`
+
+	args := []any{record.Status, header, record.Body, record.Owner, record.Key}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err = m.DB.ExecContext(ctx, query, args...)
+	return err
+}
+
+// Fail marks a key whose request was processed but whose response could not
+// be stored, so that retries are not processed again.
+func (m IdempotencyKeyModel) Fail(owner, key string) error {
+	query := `
+        UPDATE idempotency_keys
+        SET failed = true
+        WHERE owner = $1 AND key = $2 AND status IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, owner, key)
+	return err
+}
+
+// Release frees a key whose request failed without a response worth
+// replaying, so that it can be retried.
+func (m IdempotencyKeyModel) Release(owner, key string) error {
+	query := `
+        DELETE FROM idempotency_keys
+        WHERE owner = $1 AND key = $2 AND status IS NULL AND NOT failed`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, owner, key)
+	return err
+}
+
+// DeleteExpired removes the expired keys, returning how many were removed.
+func (m IdempotencyKeyModel) DeleteExpired() (int64, error) {
+	query := `
+        DELETE FROM idempotency_keys
+        WHERE expires_at < NOW()`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
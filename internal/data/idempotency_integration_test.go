@@ -0,0 +1,135 @@
+//go:build integration
+// +build integration
+
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func idempotencyKeyModelTestsTeardown(t *testing.T) {
+	t.Helper()
+
+	query := `TRUNCATE TABLE idempotency_keys`
+
+	_, err := testDB.Exec(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIdempotencyKeyModelReserve(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	hash := []byte("request hash")
+
+	t.Run("Reserve a key, then find it in flight and completed", func(t *testing.T) {
+		record, err := testModels.IdempotencyKeys.Reserve("user:1", "key", hash, time.Hour, time.Minute)
+		require.NoError(t, err)
+		require.Nil(t, record)
+
+		record, err = testModels.IdempotencyKeys.Reserve("user:1", "key", hash, time.Hour, time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		require.True(t, record.InFlight())
+
+		err = testModels.IdempotencyKeys.Complete(&IdempotencyKey{
+			Owner:  "user:1",
+			Key:    "key",
+			Status: 201,
+			Header: map[string][]string{"Content-Type": {"application/json"}},
+			Body:   []byte(`{"movie":{"id":1}}`),
+		})
+		require.NoError(t, err)
+
+		record, err = testModels.IdempotencyKeys.Reserve("user:1", "key", []byte("other hash"), time.Hour, time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		require.Equal(t, hash, record.RequestHash)
+		require.Equal(t, 201, record.Status)
+		require.Equal(t, []string{"application/json"}, record.Header["Content-Type"])
+		require.Equal(t, `{"movie":{"id":1}}`, string(record.Body))
+
+		record, err = testModels.IdempotencyKeys.Reserve("user:2", "key", hash, time.Hour, time.Minute)
+		require.NoError(t, err)
+		require.Nil(t, record)
+
+		t.Cleanup(func() {
+			idempotencyKeyModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Released, expired and abandoned keys can be reserved again", func(t *testing.T) {
+		_, err := testModels.IdempotencyKeys.Reserve("user:1", "released", hash, time.Hour, time.Minute)
+		require.NoError(t, err)
+
+		err = testModels.IdempotencyKeys.Release("user:1", "released")
+		require.NoError(t, err)
+
+		record, err := testModels.IdempotencyKeys.Reserve("user:1", "released", hash, time.Hour, time.Minute)
+		require.NoError(t, err)
+		require.Nil(t, record)
+
+		_, err = testModels.IdempotencyKeys.Reserve("user:1", "expired", hash, -time.Hour, time.Minute)
+		require.NoError(t, err)
+
+		record, err = testModels.IdempotencyKeys.Reserve("user:1", "expired", []byte("other hash"), time.Hour, time.Minute)
+		require.NoError(t, err)
+		require.Nil(t, record)
+
+		_, err = testModels.IdempotencyKeys.Reserve("user:1", "abandoned", hash, time.Hour, time.Minute)
+		require.NoError(t, err)
+
+		record, err = testModels.IdempotencyKeys.Reserve("user:1", "abandoned", []byte("other hash"), time.Hour, -time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+
+		record, err = testModels.IdempotencyKeys.Reserve("user:1", "abandoned", hash, time.Hour, -time.Minute)
+		require.NoError(t, err)
+		require.Nil(t, record)
+
+		t.Cleanup(func() {
+			idempotencyKeyModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Failed keys are not freed before they expire", func(t *testing.T) {
+		_, err := testModels.IdempotencyKeys.Reserve("user:1", "failed", hash, time.Hour, time.Minute)
+		require.NoError(t, err)
+
+		err = testModels.IdempotencyKeys.Fail("user:1", "failed")
+		require.NoError(t, err)
+
+		err = testModels.IdempotencyKeys.Release("user:1", "failed")
+		require.NoError(t, err)
+
+		record, err := testModels.IdempotencyKeys.Reserve("user:1", "failed", hash, time.Hour, -time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		require.True(t, record.Failed)
+		require.False(t, record.InFlight())
+
+		t.Cleanup(func() {
+			idempotencyKeyModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Delete the expired keys", func(t *testing.T) {
+		_, err := testModels.IdempotencyKeys.Reserve("user:1", "expired", hash, -time.Hour, time.Minute)
+		require.NoError(t, err)
+
+		_, err = testModels.IdempotencyKeys.Reserve("user:1", "live", hash, time.Hour, time.Minute)
+		require.NoError(t, err)
+
+		purged, err := testModels.IdempotencyKeys.DeleteExpired()
+		require.NoError(t, err)
+		require.Equal(t, int64(1), purged)
+
+		t.Cleanup(func() {
+			idempotencyKeyModelTestsTeardown(t)
+		})
+	})
+}
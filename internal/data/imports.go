@@ -0,0 +1,220 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+const (
+	ImportRowCreated = "created"
+	ImportRowFailed  = "failed"
+)
+
+// MovieImport tracks a bulk import of movies and keeps a per-row report of
+// its outcome.
+type MovieImport struct {
+	ID        int64       `json:"id"`
+	UserID    int64       `json:"-"`
+	Format    string      `json:"format"`
+	Status    string      `json:"status"`
+	Total     int         `json:"total"`
+	Created   int         `json:"created"`
+	Failed    int         `json:"failed"`
+	Rows      []ImportRow `json:"rows"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+}
+
+// ImportRow is the outcome of a single row of an import. Line is the line
+// number of the row in the uploaded file. Movie holds the parsed movie while
+// the import is running and is not part of the report.
+type ImportRow struct {
+	Line    int               `json:"line"`
+	Status  string            `json:"status"`
+	MovieID int64             `json:"movieId,omitempty"`
+	Errors  map[string]string `json:"errors,omitempty"`
+	Movie   *Movie            `json:"-"`
+}
+
+// Fail marks the row as failed with the given errors.
+func (r *ImportRow) Fail(errs map[string]string) {
+	r.Status = ImportRowFailed
+	r.Errors = errs
+	r.Movie = nil
+}
+
+// Tally recomputes the row counters from the report.
+func (i *MovieImport) Tally() {
+	i.Total = len(i.Rows)
+	i.Created = 0
+	i.Failed = 0
+
+	for _, row := range i.Rows {
+		switch row.Status {
+		case ImportRowCreated:
+			i.Created++
+		case ImportRowFailed:
+			i.Failed++
+		}
+	}
+}
+
+type ImportModel struct {
+	DB *sql.DB
+}
+
+func (m ImportModel) Insert(movieImport *MovieImport) error {
+	report, err := json.Marshal(movieImport.Rows)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO movie_imports (user_id, format, status, total, created, failed, report)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at, updated_at`
+
+	args := []any{
+		movieImport.UserID,
+		movieImport.Format,
+		movieImport.Status,
+		movieImport.Total,
+		movieImport.Created,
+		movieImport.Failed,
+		string(report),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&movieImport.ID, &movieImport.CreatedAt, &movieImport.UpdatedAt)
+}
+
+// Get returns the import only when it belongs to the given user.
+func (m ImportModel) Get(id, userID int64) (*MovieImport, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+        SELECT id, user_id, format, status, total, created, failed, report, created_at, updated_at
+        FROM movie_imports
+        WHERE id = $1 AND user_id = $2`
+
+	var (
+		movieImport MovieImport
+		report      []byte
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id, userID).Scan(
+		&movieImport.ID,
+		&movieImport.UserID,
+		&movieImport.Format,
+		&movieImport.Status,
+		&movieImport.Total,
+		&movieImport.Created,
+		&movieImport.Failed,
+		&report,
+		&movieImport.CreatedAt,
+		&movieImport.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = json.Unmarshal(report, &movieImport.Rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &movieImport, nil
+}
+
+func (m ImportModel) Update(movieImport *MovieImport) error {
+	report, err := json.Marshal(movieImport.Rows)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        UPDATE movie_imports
+        SET status = $1, total = $2, created = $3, failed = $4, report = $5, updated_at = NOW()
+        WHERE id = $6
+        RETURNING updated_at`
+
+	args := []any{
+		movieImport.Status,
+		movieImport.Total,
+		movieImport.Created,
+		movieImport.Failed,
+		string(report),
+		movieImport.ID,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = m.DB.QueryRowContext(ctx, query, args...).Scan(&movieImport.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FailStale marks the imports still pending or running after not being
+// updated for the given duration as failed, along with their unprocessed
+// rows. Running imports are saved after every batch, so those were
+// interrupted, usually by a restart, and will not complete.
+func (m ImportModel) FailStale(olderThan time.Duration) (int64, error) {
+	query := `
+        UPDATE movie_imports
+        SET status = 'failed', updated_at = NOW(),
+        failed = (SELECT count(*) FROM jsonb_array_elements(report) AS r(row) WHERE row->>'status' <> 'created'),
+        report = (
+            SELECT COALESCE(jsonb_agg(
+                CASE WHEN row->>'status' = '' THEN row || '{"status": "failed", "errors": {"row": "was not processed, the import was interrupted"}}' ELSE row END
+                ORDER BY position
+            ), '[]')
+            FROM jsonb_array_elements(report) WITH ORDINALITY AS r(row, position)
+        )
+        WHERE status IN ('pending', 'running') AND updated_at < $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, time.Now().Add(-olderThan))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,171 @@
+//go:build integration
+// +build integration
+
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+)
+
+// importModelTestsTeardown it's a helper to truncate the `movies`
+// and `users` tables, and by cascade the imports, during tests.
+func importModelTestsTeardown(t *testing.T) {
+	t.Helper()
+
+	movieModelTestsTeardown(t)
+	userModelTestsTeardown(t)
+}
+
+func TestImportModel(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully insert, update and get an import", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+
+		movies := []*Movie{
+			{Title: gofakeit.MovieName(), Year: 2001, Runtime: 120, Genres: []string{"drama"}},
+			{Title: gofakeit.MovieName(), Year: 2002, Runtime: 95, Genres: []string{"comedy"}},
+		}
+
+		movieImport := &MovieImport{
+			UserID: user.ID,
+			Format: ImportFormatCSV,
+			Status: ImportStatusPending,
+			Rows: []ImportRow{
+				{Line: 2, Movie: movies[0]},
+				{Line: 3, Movie: movies[1]},
+				{Line: 4, Status: ImportRowFailed, Errors: map[string]string{"title": "must be provided"}},
+			},
+		}
+
+		movieImport.Tally()
+
+		err := testModels.Imports.Insert(movieImport)
+		require.NoError(t, err)
+		require.NotZero(t, movieImport.ID)
+		require.Equal(t, 3, movieImport.Total)
+		require.Equal(t, 1, movieImport.Failed)
+
+		errs, err := testModels.Movies.InsertBatch(movies)
+		require.NoError(t, err)
+		require.Equal(t, []error{nil, nil}, errs)
+
+		for i, movie := range movies {
+			require.NotZero(t, movie.ID)
+
+			movieImport.Rows[i].Status = ImportRowCreated
+			movieImport.Rows[i].MovieID = movie.ID
+		}
+
+		movieImport.Status = ImportStatusCompleted
+		movieImport.Tally()
+
+		err = testModels.Imports.Update(movieImport)
+		require.NoError(t, err)
+
+		gotImport, err := testModels.Imports.Get(movieImport.ID, user.ID)
+		require.NoError(t, err)
+		require.Equal(t, ImportStatusCompleted, gotImport.Status)
+		require.Equal(t, 2, gotImport.Created)
+		require.Equal(t, 1, gotImport.Failed)
+		require.Len(t, gotImport.Rows, 3)
+		require.Equal(t, movies[1].ID, gotImport.Rows[1].MovieID)
+		require.Equal(t, "must be provided", gotImport.Rows[2].Errors["title"])
+
+		gotMovie, err := testModels.Movies.Get(movies[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, movies[0].Title, gotMovie.Title)
+
+		t.Cleanup(func() {
+			importModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("A movie failing to insert does not affect the others", func(t *testing.T) {
+		movies := []*Movie{
+			{Title: gofakeit.MovieName(), Year: 2001, Runtime: 120, Genres: []string{"drama"}},
+			{Title: gofakeit.MovieName(), Year: 2002, Runtime: -1, Genres: []string{"drama"}},
+			{Title: gofakeit.MovieName(), Year: 2003, Runtime: 95, Genres: []string{"comedy"}},
+		}
+
+		errs, err := testModels.Movies.InsertBatch(movies)
+		require.NoError(t, err)
+		require.NoError(t, errs[0])
+		require.Error(t, errs[1])
+		require.NoError(t, errs[2])
+
+		_, err = testModels.Movies.Get(movies[2].ID)
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			importModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Fail the interrupted imports", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+
+		movieImport := &MovieImport{
+			UserID: user.ID,
+			Format: ImportFormatNDJSON,
+			Status: ImportStatusRunning,
+			Rows: []ImportRow{
+				{Line: 1, Status: ImportRowCreated, MovieID: 1},
+				{Line: 2},
+				{Line: 3, Status: ImportRowFailed, Errors: map[string]string{"title": "must be provided"}},
+			},
+		}
+
+		movieImport.Tally()
+
+		err := testModels.Imports.Insert(movieImport)
+		require.NoError(t, err)
+
+		failed, err := testModels.Imports.FailStale(-time.Minute)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), failed)
+
+		gotImport, err := testModels.Imports.Get(movieImport.ID, user.ID)
+		require.NoError(t, err)
+		require.Equal(t, ImportStatusFailed, gotImport.Status)
+		require.Equal(t, 1, gotImport.Created)
+		require.Equal(t, 2, gotImport.Failed)
+		require.Equal(t, ImportRowFailed, gotImport.Rows[1].Status)
+		require.Equal(t, "must be provided", gotImport.Rows[2].Errors["title"])
+
+		t.Cleanup(func() {
+			importModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrRecordNotFound' when the import belongs to another user", func(t *testing.T) {
+		owner := createRandomUser(t, &testModels)
+		other := createRandomUser(t, &testModels)
+
+		movieImport := &MovieImport{
+			UserID: owner.ID,
+			Format: ImportFormatNDJSON,
+			Status: ImportStatusPending,
+			Rows:   []ImportRow{},
+		}
+
+		err := testModels.Imports.Insert(movieImport)
+		require.NoError(t, err)
+
+		_, err = testModels.Imports.Get(movieImport.ID, other.ID)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		t.Cleanup(func() {
+			importModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrRecordNotFound' when given 'ID' is lower than 1", func(t *testing.T) {
+		_, err := testModels.Imports.Get(0, 1)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	})
+}
@@ -11,17 +11,47 @@ var (
 )
 
 type Models struct {
-	Movies      MovieModel
-	Permissions PermissionModel
-	Tokens      TokenModel
-	Users       UserModel
+	Collections       CollectionModel
+	Credits           CreditModel
+	Genres            GenreModel
+	IdempotencyKeys   IdempotencyKeyModel
+	Imports           ImportModel
+	MovieEvents       MovieEventModel
+	Movies            MovieModel
+	People            PersonModel
+	Permissions       PermissionModel
+	Ratings           RatingModel
+	Reviews           ReviewModel
+	Revisions         RevisionModel
+	Titles            TitleModel
+	Tokens            TokenModel
+	Users             UserModel
+	Watched           WatchedModel
+	Watchlist         WatchlistModel
+	WebhookDeliveries WebhookDeliveryModel
+	Webhooks          WebhookModel
 }
 
 func NewModels(db *sql.DB) Models {
 	return Models{
-		Movies:      MovieModel{DB: db},
-		Permissions: PermissionModel{DB: db},
-		Tokens:      TokenModel{DB: db},
-		Users:       UserModel{DB: db},
+		Collections:       CollectionModel{DB: db},
+		Credits:           CreditModel{DB: db},
+		Genres:            GenreModel{DB: db, catalogue: &genreCatalogue{}},
+		IdempotencyKeys:   IdempotencyKeyModel{DB: db},
+		Imports:           ImportModel{DB: db},
+		MovieEvents:       MovieEventModel{DB: db},
+		Movies:            MovieModel{DB: db},
+		People:            PersonModel{DB: db},
+		Permissions:       PermissionModel{DB: db},
+		Ratings:           RatingModel{DB: db},
+		Reviews:           ReviewModel{DB: db},
+		Revisions:         RevisionModel{DB: db},
+		Titles:            TitleModel{DB: db},
+		Tokens:            TokenModel{DB: db},
+		Users:             UserModel{DB: db},
+		Watched:           WatchedModel{DB: db},
+		Watchlist:         WatchlistModel{DB: db},
+		WebhookDeliveries: WebhookDeliveryModel{DB: db},
+		Webhooks:          WebhookModel{DB: db},
 	}
 }
@@ -5,26 +5,73 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/brGuirra/greenlight/internal/validator"
 	"github.com/lib/pq"
 )
 
-var MoviesSortSafeList = []string{"id", "title", "year", "runtime", "-id", "-title", "-year", "-runtime"}
+var (
+	MoviesSortSafeList = []string{"id", "title", "year", "runtime", "rating", "-id", "-title", "-year", "-runtime", "-rating"}
+
+	// MoviesFieldsSafeList holds the fields a movie response can be
+	// trimmed to, and MoviesIncludeSafeList the related data it can embed.
+	MoviesFieldsSafeList  = []string{"id", "title", "titles", "localizedTitle", "genres", "year", "runtime", "poster", "rating", "ratingCount", "version"}
+	MoviesIncludeSafeList = []string{"credits"}
+)
 
 type Movie struct {
-	ID        int64     `json:"id"`
-	Title     string    `json:"title"`
-	Genres    []string  `json:"genres,omitempty"`
-	Year      int32     `json:"year,omitempty"`
-	Runtime   Runtime   `json:"runtime,omitempty"`
-	Version   int32     `json:"version"`
-	CreatedAt time.Time `json:"-"`
+	ID             int64      `json:"id"`
+	Title          string     `json:"title"`
+	Titles         Titles     `json:"titles,omitempty"`
+	LocalizedTitle string     `json:"localizedTitle,omitempty"`
+	Genres         []string   `json:"genres,omitempty"`
+	Year           int32      `json:"year,omitempty"`
+	Runtime        Runtime    `json:"runtime,omitempty"`
+	Credits        []Credit   `json:"credits,omitempty"`
+	Poster         *Poster    `json:"poster,omitempty"`
+	Rating         float64    `json:"rating"`
+	RatingCount    int32      `json:"ratingCount"`
+	Version        int32      `json:"version"`
+	CreatedAt      time.Time  `json:"-"`
+	UpdatedAt      time.Time  `json:"-"`
+	DeletedAt      *time.Time `json:"deletedAt,omitempty"`
+}
+
+// Localize sets the localized title to the alternate title that best
+// matches the languages, falling back to the original title.
+func (m *Movie) Localize(languages []string) {
+	if title, ok := m.Titles.Lookup(languages); ok {
+		m.LocalizedTitle = title
+		return
+	}
+
+	m.LocalizedTitle = m.Title
+}
+
+// MovieQuery holds the search criteria accepted by MovieModel.GetAll and
+// MovieModel.Export.
+// Zero values disable the corresponding criterion.
+type MovieQuery struct {
+	Title     string
+	Genres    []string
+	GenresAny []string
+	GenresNot []string
+	Director  string
+	// InWatchlistOf restricts the results to the watchlist of the
+	// given user ID.
+	InWatchlistOf int64
+	// Deleted lists the soft-deleted movies in the trash instead of the
+	// live ones.
+	Deleted bool
+	// Fields restricts the columns read to those backing the given
+	// fields from MoviesFieldsSafeList, every column is read when empty.
+	Fields []string
 }
 
 // TODO: Test at handler level
-func ValidateMovie(v *validator.Validator, movie *Movie) {
+func ValidateMovie(v *validator.Validator, movie *Movie, genres Genres) {
 	v.Check(movie.Title != "", "title", "must be provided")
 	v.Check(len(movie.Title) <= 500, "title", "must not be more than 500 bytes long")
 
@@ -39,6 +86,31 @@ func ValidateMovie(v *validator.Validator, movie *Movie) {
 	v.Check(len(movie.Genres) >= 1, "genres", "must contain at least 1 genre")
 	v.Check(len(movie.Genres) <= 5, "genres", "must not contain more than 5 genres")
 	v.Check(validator.Unique(movie.Genres), "genres", "must not contain duplicate values")
+
+	for _, genre := range movie.Genres {
+		v.Check(genres.Include(genre), "genres", "must contain only known genres")
+	}
+}
+
+// ValidateGenresFilters checks the genres_any and genres_not filters, and
+// that no genre is both required by genres or genres_any and excluded by
+// genres_not. The genres filter itself is accepted as is.
+func ValidateGenresFilters(v *validator.Validator, genres, genresAny, genresNot []string) {
+	checkGenres := func(key string, values []string) {
+		v.Check(len(values) <= 10, key, "must not contain more than 10 genres")
+		v.Check(validator.Unique(values), key, "must not contain duplicate values")
+		v.Check(!validator.PermittedValue("", values...), key, "must not contain empty values")
+	}
+
+	checkGenres("genres_any", genresAny)
+	checkGenres("genres_not", genresNot)
+
+	for _, genre := range genresNot {
+		if validator.PermittedValue(genre, genres...) || validator.PermittedValue(genre, genresAny...) {
+			v.AddError("genres_not", "must not contain genres that are also required")
+			break
+		}
+	}
 }
 
 type MovieModel struct {
@@ -46,43 +118,110 @@ type MovieModel struct {
 }
 
 func (m MovieModel) Insert(movie *Movie) error {
-	query := `
-        INSERT INTO movies (title, year, runtime, genres)
-        VALUES ($1, $2, $3, $4)
-        RETURNING id, created_at, version`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
 
-	args := []any{movie.Title, movie.Year, movie.Runtime, pq.Array(movie.Genres)}
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer tx.Rollback()
+
+	err = insertMovie(ctx, tx, movie)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// InsertBatch inserts the movies in a single transaction and returns the
+// outcome of each one. Every insert runs in its own savepoint, so a movie
+// that fails is skipped without affecting the others.
+func (m MovieModel) InsertBatch(movies []*Movie) ([]error, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	return m.DB.QueryRowContext(ctx, query, args...).Scan(&movie.ID, &movie.CreatedAt, &movie.Version)
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	errs := make([]error, len(movies))
+
+	for i, movie := range movies {
+		_, err = tx.ExecContext(ctx, `SAVEPOINT insert_movie`)
+		if err != nil {
+			return nil, err
+		}
+
+		errs[i] = insertMovie(ctx, tx, movie)
+
+		if errs[i] != nil {
+			_, err = tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT insert_movie`)
+		} else {
+			_, err = tx.ExecContext(ctx, `RELEASE SAVEPOINT insert_movie`)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return errs, tx.Commit()
 }
 
 func (m MovieModel) Get(id int64) (Movie, error) {
+	return m.GetFields(id, nil)
+}
+
+// GetFields returns the movie reading only the columns backing the given
+// fields from MoviesFieldsSafeList, every column is read when empty.
+func (m MovieModel) GetFields(id int64, fields []string) (Movie, error) {
 	if id < 1 {
 		return Movie{}, ErrRecordNotFound
 	}
 
-	query := `
-        SELECT id, created_at, title, year, runtime, genres, version
+	columns, dest := selectMovieColumns(fields)
+
+	query := fmt.Sprintf(`
+        SELECT %s
         FROM movies
-        WHERE id = $1`
+        WHERE id = $1 AND deleted_at IS NULL`, columns)
 
 	var movie Movie
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, query, id).Scan(
-		&movie.ID,
-		&movie.CreatedAt,
-		&movie.Title,
-		&movie.Year,
-		&movie.Runtime,
-		pq.Array(&movie.Genres),
-		&movie.Version,
-	)
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(dest(&movie)...)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return Movie{}, ErrRecordNotFound
+		default:
+			return Movie{}, err
+		}
+	}
+
+	return movie, nil
+}
+
+// getMovie reads every column of the movie within the transaction.
+func getMovie(ctx context.Context, tx *sql.Tx, id int64) (Movie, error) {
+	columns, dest := selectMovieColumns(nil)
+
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM movies
+        WHERE id = $1 AND deleted_at IS NULL`, columns)
+
+	var movie Movie
+
+	err := tx.QueryRowContext(ctx, query, id).Scan(dest(&movie)...)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -95,12 +234,82 @@ func (m MovieModel) Get(id int64) (Movie, error) {
 	return movie, nil
 }
 
-func (m MovieModel) Update(movie *Movie) error {
+// Update saves the movie, keeping a snapshot of its previous state and the
+// acting user in the revision history.
+func (m MovieModel) Update(movie *Movie, userID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	err = updateMovie(ctx, tx, movie, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete moves the movie to the trash, keeping a snapshot of its last
+// state and the acting user in the revision history. Trashed movies are
+// ignored by every other MovieModel method until restored, and are purged
+// for good by Purge. A zero version deletes whatever the current version
+// is, otherwise a mismatch is reported as ErrEditConflict.
+func (m MovieModel) Delete(id int64, version int32, userID int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	err = deleteMovie(ctx, tx, id, version, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertMovie(ctx context.Context, tx *sql.Tx, movie *Movie) error {
+	query := `
+        INSERT INTO movies (title, year, runtime, genres)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at, version`
+
+	args := []any{movie.Title, movie.Year, movie.Runtime, pq.Array(movie.Genres)}
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&movie.ID, &movie.CreatedAt, &movie.Version)
+	if err != nil {
+		return err
+	}
+
+	return recordMovieEvent(ctx, tx, EventMovieCreated, movie.ID)
+}
+
+func updateMovie(ctx context.Context, tx *sql.Tx, movie *Movie, userID int64) error {
+	err := insertMovieRevision(ctx, tx, movie.ID, movie.Version, RevisionActionUpdate, userID)
+	if err != nil {
+		return err
+	}
+
 	query := `
         UPDATE movies 
-        SET title = $1, year = $2, runtime = $3, genres = $4, version = version + 1
-        WHERE id = $5 AND version = $6
-        RETURNING version`
+        SET title = $1, year = $2, runtime = $3, genres = $4, updated_at = NOW(), version = version + 1
+        WHERE id = $5 AND version = $6 AND deleted_at IS NULL
+        RETURNING version, updated_at`
 
 	args := []any{
 		movie.Title,
@@ -111,36 +320,118 @@ func (m MovieModel) Update(movie *Movie) error {
 		movie.Version,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&movie.Version)
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&movie.Version, &movie.UpdatedAt)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
 			return ErrEditConflict
 		default:
 			return err
+		}
+	}
 
+	return recordMovieEvent(ctx, tx, EventMovieUpdated, movie.ID)
+}
+
+// touchMovie gives the movie a new version, for changes made to the records
+// shown along with it. It returns ErrEditConflict when the movie is not at
+// the expected version anymore.
+func touchMovie(ctx context.Context, tx *sql.Tx, movie *Movie) error {
+	query := `
+        UPDATE movies
+        SET updated_at = NOW(), version = version + 1
+        WHERE id = $1 AND version = $2 AND deleted_at IS NULL
+        RETURNING version, updated_at`
+
+	err := tx.QueryRowContext(ctx, query, movie.ID, movie.Version).Scan(&movie.Version, &movie.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
 		}
 	}
 
 	return nil
 }
 
-func (m MovieModel) Delete(id int64) error {
+// deleteMovie trashes the movie after recording its revision. A zero
+// version deletes whatever the current version is, otherwise a mismatch is
+// reported as ErrEditConflict.
+func deleteMovie(ctx context.Context, tx *sql.Tx, id int64, version int32, userID int64) error {
+	query := `
+        INSERT INTO movie_revisions (movie_id, version, title, year, runtime, genres, action, user_id)
+        SELECT id, version, title, year, runtime, genres, $2, NULLIF($3, 0)
+        FROM movies
+        WHERE id = $1 AND deleted_at IS NULL AND ($4 = 0 OR version = $4)
+        FOR UPDATE`
+
+	result, err := tx.ExecContext(ctx, query, id, RevisionActionDelete, userID, version)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		if version == 0 {
+			return ErrRecordNotFound
+		}
+
+		var exists bool
+
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM movies WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return ErrEditConflict
+		}
+
+		return ErrRecordNotFound
+	}
+
+	query = `
+        UPDATE movies
+        SET deleted_at = NOW(), updated_at = NOW(), version = version + 1
+        WHERE id = $1`
+
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return recordMovieEvent(ctx, tx, EventMovieDeleted, id)
+}
+
+// Restore takes a movie out of the trash. It is reported as created again,
+// for the subscribers that dropped it when it was deleted.
+func (m MovieModel) Restore(id int64) error {
 	if id < 1 {
 		return ErrRecordNotFound
 	}
 
 	query := `
-        DELETE FROM movies
-        WHERE id = $1`
+        UPDATE movies
+        SET deleted_at = NULL, updated_at = NOW(), version = version + 1
+        WHERE id = $1 AND deleted_at IS NOT NULL`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	result, err := m.DB.ExecContext(ctx, query, id)
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -154,22 +445,169 @@ func (m MovieModel) Delete(id int64) error {
 		return ErrRecordNotFound
 	}
 
-	return nil
+	err = recordMovieEvent(ctx, tx, EventMovieCreated, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Purge permanently deletes the movies that have been in the trash for
+// longer than the given duration, returning how many were removed. The
+// posters of the removed movies are returned too, so their files can be
+// cleaned up.
+func (m MovieModel) Purge(olderThan time.Duration) (int64, []*Poster, error) {
+	query := `
+        DELETE FROM movies
+        WHERE deleted_at < $1
+        RETURNING poster`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, time.Now().Add(-olderThan))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	defer rows.Close()
+
+	purged := int64(0)
+	posters := []*Poster{}
+
+	for rows.Next() {
+		var poster *Poster
+
+		err = rows.Scan(&poster)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		purged++
+
+		if poster != nil {
+			posters = append(posters, poster)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	return purged, posters, nil
+}
+
+// movieColumns lists the columns a movie is read from, with the fields
+// from MoviesFieldsSafeList needing them. Columns without fields are always
+// read, as they are needed to identify the movie and its version.
+var movieColumns = []struct {
+	fields []string
+	expr   string
+	dest   func(*Movie) any
+}{
+	{nil, "id", func(m *Movie) any { return &m.ID }},
+	{nil, "created_at", func(m *Movie) any { return &m.CreatedAt }},
+	{nil, "updated_at", func(m *Movie) any { return &m.UpdatedAt }},
+	{[]string{"title", "localizedTitle"}, "title", func(m *Movie) any { return &m.Title }},
+	{[]string{"titles", "localizedTitle"}, movieTitlesColumn, func(m *Movie) any { return &m.Titles }},
+	{[]string{"year"}, "year", func(m *Movie) any { return &m.Year }},
+	{[]string{"runtime"}, "runtime", func(m *Movie) any { return &m.Runtime }},
+	{[]string{"genres"}, "genres", func(m *Movie) any { return pq.Array(&m.Genres) }},
+	{[]string{"rating"}, "rating", func(m *Movie) any { return &m.Rating }},
+	{[]string{"ratingCount"}, "rating_count", func(m *Movie) any { return &m.RatingCount }},
+	{nil, "version", func(m *Movie) any { return &m.Version }},
+	{[]string{"poster"}, "poster", func(m *Movie) any { return &m.Poster }},
+}
+
+// selectMovieColumns returns the select list for the given fields, every
+// column when empty, along with a function returning the scan destinations
+// in a movie for those columns.
+func selectMovieColumns(fields []string) (string, func(*Movie) []any) {
+	var exprs []string
+	var dests []func(*Movie) any
+
+	for _, column := range movieColumns {
+		selected := len(fields) == 0 || column.fields == nil
+
+		for _, field := range column.fields {
+			if validator.PermittedValue(field, fields...) {
+				selected = true
+			}
+		}
+
+		if selected {
+			exprs = append(exprs, column.expr)
+			dests = append(dests, column.dest)
+		}
+	}
+
+	dest := func(movie *Movie) []any {
+		values := make([]any, len(dests))
+
+		for i := range dests {
+			values[i] = dests[i](movie)
+		}
+
+		return values
+	}
+
+	return strings.Join(exprs, ", "), dest
+}
+
+// movieQueryConditions is the WHERE clause matching a MovieQuery, taking
+// the values returned by MovieQuery.args as its first seven parameters.
+const movieQueryConditions = `(deleted_at IS NOT NULL) = $7
+        AND ($1 = '' OR to_tsvector('simple', title) @@ plainto_tsquery('simple', $1) OR EXISTS (
+            SELECT 1
+            FROM movie_titles
+            WHERE movie_titles.movie_id = movies.id
+            AND to_tsvector('simple', movie_titles.title) @@ plainto_tsquery('simple', $1)
+        ))
+        AND (genres @> $2 OR COALESCE($2, '{}') = '{}')
+        AND (genres && $3 OR COALESCE($3, '{}') = '{}')
+        AND (NOT genres && $4 OR COALESCE($4, '{}') = '{}')
+        AND ($5 = '' OR EXISTS (
+            SELECT 1
+            FROM movie_credits
+            INNER JOIN people ON people.id = movie_credits.person_id
+            WHERE movie_credits.movie_id = movies.id
+            AND movie_credits.role = 'director'
+            AND to_tsvector('simple', people.name) @@ plainto_tsquery('simple', $5)
+        ))
+        AND ($6 = 0 OR EXISTS (
+            SELECT 1
+            FROM watchlist_items
+            WHERE watchlist_items.movie_id = movies.id
+            AND watchlist_items.user_id = $6
+        ))`
+
+func (q MovieQuery) args() []any {
+	return []any{
+		q.Title,
+		pq.Array(q.Genres),
+		pq.Array(q.GenresAny),
+		pq.Array(q.GenresNot),
+		q.Director,
+		q.InWatchlistOf,
+		q.Deleted,
+	}
 }
 
-func (m MovieModel) GetAll(title string, genres []string, filters Filters) ([]Movie, Metadata, error) {
+func (m MovieModel) GetAll(q MovieQuery, filters Filters) ([]Movie, Metadata, error) {
+	columns, dest := selectMovieColumns(q.Fields)
+
 	query := fmt.Sprintf(`
-        SELECT count(*) OVER(), id, created_at, title, year, runtime, genres, version
+        SELECT count(*) OVER(), %s, deleted_at
         FROM movies
-        WHERE (to_tsvector('simple', title) @@ plainto_tsquery('simple', $1) OR $1 = '')
-        AND (genres @> $2 OR $2 = '{}')
+        WHERE %s
         ORDER BY %s %s, id ASC
-        LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+        LIMIT $8 OFFSET $9`, columns, movieQueryConditions, filters.sortColumn(), filters.sortDirection())
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	args := []any{title, pq.Array(genres), filters.limit(), filters.offsett()}
+	args := append(q.args(), filters.limit(), filters.offsett())
 
 	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -184,16 +622,9 @@ func (m MovieModel) GetAll(title string, genres []string, filters Filters) ([]Mo
 	for rows.Next() {
 		var movie Movie
 
-		err := rows.Scan(
-			&totalRecords,
-			&movie.ID,
-			&movie.CreatedAt,
-			&movie.Title,
-			&movie.Year,
-			&movie.Runtime,
-			pq.Array(&movie.Genres),
-			&movie.Version,
-		)
+		values := append([]any{&totalRecords}, dest(&movie)...)
+
+		err := rows.Scan(append(values, &movie.DeletedAt)...)
 		if err != nil {
 			return nil, Metadata{}, err
 		}
@@ -209,3 +640,159 @@ func (m MovieModel) GetAll(title string, genres []string, filters Filters) ([]Mo
 
 	return movies, metadata, nil
 }
+
+// exportFetchSize is the number of rows read from the export cursor at once.
+const exportFetchSize = 500
+
+// Export streams every movie matching the query to fn, in the order given by
+// the filters. Pagination is ignored. The rows are read through a
+// server-side cursor, so only exportFetchSize movies are held in memory at
+// a time, and the export stops as soon as ctx is cancelled or fn returns an
+// error.
+func (m MovieModel) Export(ctx context.Context, q MovieQuery, filters Filters, fn func(*Movie) error) error {
+	tx, err := m.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	columns, dest := selectMovieColumns(q.Fields)
+
+	query := fmt.Sprintf(`
+        DECLARE movies_export NO SCROLL CURSOR FOR
+        SELECT %s, deleted_at
+        FROM movies
+        WHERE %s
+        ORDER BY %s %s, id ASC`, columns, movieQueryConditions, filters.sortColumn(), filters.sortDirection())
+
+	_, err = tx.ExecContext(ctx, query, q.args()...)
+	if err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM movies_export", exportFetchSize)
+
+	for {
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			return err
+		}
+
+		fetched := 0
+
+		for rows.Next() {
+			var movie Movie
+
+			err := rows.Scan(append(dest(&movie), &movie.DeletedAt)...)
+			if err == nil {
+				err = fn(&movie)
+			}
+
+			if err != nil {
+				rows.Close()
+				return err
+			}
+
+			fetched++
+		}
+
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		if fetched < exportFetchSize {
+			return tx.Commit()
+		}
+	}
+}
+
+const (
+	MovieOperationCreate = "create"
+	MovieOperationUpdate = "update"
+	MovieOperationDelete = "delete"
+)
+
+var ErrBatchAborted = errors.New("batch aborted")
+
+// MovieOperation is a single change applied by MovieModel.Batch. Updates
+// and deletes only apply to the movie at Movie.Version.
+type MovieOperation struct {
+	Action string
+	Movie  *Movie
+}
+
+// Batch applies the operations in order and returns the outcome of each one.
+// When atomic is set they share a transaction that is rolled back on the
+// first failure: the failing operation gets its own error and every other
+// one gets ErrBatchAborted. Otherwise each operation is committed on its own.
+func (m MovieModel) Batch(ops []MovieOperation, atomic bool, userID int64) ([]error, error) {
+	errs := make([]error, len(ops))
+
+	if !atomic {
+		for i := range ops {
+			errs[i] = m.applyOperation(ops[i], userID)
+		}
+
+		return errs, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	for i := range ops {
+		err = applyMovieOperation(ctx, tx, ops[i], userID)
+		if err != nil {
+			for j := range errs {
+				errs[j] = ErrBatchAborted
+			}
+
+			errs[i] = err
+
+			return errs, nil
+		}
+	}
+
+	return errs, tx.Commit()
+}
+
+func (m MovieModel) applyOperation(op MovieOperation, userID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	err = applyMovieOperation(ctx, tx, op, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func applyMovieOperation(ctx context.Context, tx *sql.Tx, op MovieOperation, userID int64) error {
+	switch op.Action {
+	case MovieOperationCreate:
+		return insertMovie(ctx, tx, op.Movie)
+	case MovieOperationUpdate:
+		return updateMovie(ctx, tx, op.Movie, userID)
+	case MovieOperationDelete:
+		return deleteMovie(ctx, tx, op.Movie.ID, op.Movie.Version, userID)
+	default:
+		return fmt.Errorf("unknown movie operation %q", op.Action)
+	}
+}
@@ -4,6 +4,8 @@
 package data
 
 import (
+	"context"
+	"errors"
 	"os"
 	"regexp"
 	"slices"
@@ -167,6 +169,43 @@ func filterMovieByGenres(genres []string, movies []Movie) []Movie {
 	return results
 }
 
+// filterMovieByAnyGenres it's a helper to filter elements
+// by a slice of genres during tests.
+// It takes in the slice of genres and a `[]Movie`,
+// and returns a new slice of `Movie` containing
+// only the movies that have at least one of the genres.
+func filterMovieByAnyGenres(genres []string, movies []Movie) []Movie {
+	results := make([]Movie, 0)
+
+	for _, m := range movies {
+		for _, g := range genres {
+			if slices.Contains(m.Genres, g) {
+				results = append(results, m)
+				break
+			}
+		}
+	}
+
+	return results
+}
+
+// filterMovieExcludingGenres it's a helper to filter elements
+// by a slice of genres during tests.
+// It takes in the slice of genres and a `[]Movie`,
+// and returns a new slice of `Movie` containing
+// only the movies that have none of the genres.
+func filterMovieExcludingGenres(genres []string, movies []Movie) []Movie {
+	results := make([]Movie, 0)
+
+	for _, m := range movies {
+		if !slices.ContainsFunc(genres, func(g string) bool { return slices.Contains(m.Genres, g) }) {
+			results = append(results, m)
+		}
+	}
+
+	return results
+}
+
 // createRandomMovie it's a helper to populate the database
 // with movies. It takes in a pointer of `testing.T` and
 // a pointer of `Models`, and returns a `Movie` created
@@ -246,7 +285,7 @@ func movieModelTestsSetup(t *testing.T) []Movie {
 func movieModelTestsTeardown(t *testing.T) {
 	t.Helper()
 
-	query := `TRUNCATE TABLE movies RESTART IDENTITY`
+	query := `TRUNCATE TABLE movies, movie_revisions RESTART IDENTITY CASCADE`
 
 	_, err := testDB.Exec(query)
 	if err != nil {
@@ -300,11 +339,13 @@ func TestMovieModelGetAll(t *testing.T) {
 	}
 
 	testCases := []struct {
-		name    string
-		title   string
-		genres  []string
-		filters Filters
-		assert  func(t *testing.T, data []Movie, meta Metadata, err error)
+		name      string
+		title     string
+		genres    []string
+		genresAny []string
+		genresNot []string
+		filters   Filters
+		assert    func(t *testing.T, data []Movie, meta Metadata, err error)
 	}{
 		{
 			name:   "Sort by 'ID' with 'ASC'",
@@ -992,6 +1033,83 @@ func TestMovieModelGetAll(t *testing.T) {
 				}, meta)
 			},
 		},
+		{
+			name:      "Filter by 'GenresAny' and sorted by 'ID' with 'ASC'",
+			title:     "",
+			genres:    []string{},
+			genresAny: []string{"Comedy", "Romance"},
+			filters: Filters{
+				Page:         1,
+				PageSize:     20,
+				Sort:         "id",
+				SortSafeList: MoviesSortSafeList,
+			},
+			assert: func(t *testing.T, data []Movie, meta Metadata, err error) {
+				expected := filterMovieByAnyGenres([]string{"Comedy", "Romance"}, movies)
+
+				require.NoError(t, err)
+				assertMovies(t, expected, data)
+				assertMetadata(t, Metadata{
+					CurrentPage:  1,
+					PageSize:     20,
+					FirstPage:    1,
+					LastPage:     1,
+					TotalRecords: 9,
+				}, meta)
+			},
+		},
+		{
+			name:      "Filter by 'GenresNot' and sorted by 'ID' with 'ASC'",
+			title:     "",
+			genres:    []string{},
+			genresNot: []string{"Action"},
+			filters: Filters{
+				Page:         1,
+				PageSize:     20,
+				Sort:         "id",
+				SortSafeList: MoviesSortSafeList,
+			},
+			assert: func(t *testing.T, data []Movie, meta Metadata, err error) {
+				expected := filterMovieExcludingGenres([]string{"Action"}, movies)
+
+				require.NoError(t, err)
+				assertMovies(t, expected[0:20], data)
+				assertMetadata(t, Metadata{
+					CurrentPage:  1,
+					PageSize:     20,
+					FirstPage:    1,
+					LastPage:     3,
+					TotalRecords: 45,
+				}, meta)
+			},
+		},
+		{
+			name:      "Filter by 'GenresAny' and 'GenresNot' and sorted by 'ID' with 'ASC'",
+			title:     "",
+			genres:    []string{},
+			genresAny: []string{"Comedy", "Romance"},
+			genresNot: []string{"Adventure"},
+			filters: Filters{
+				Page:         1,
+				PageSize:     20,
+				Sort:         "id",
+				SortSafeList: MoviesSortSafeList,
+			},
+			assert: func(t *testing.T, data []Movie, meta Metadata, err error) {
+				expected := filterMovieByAnyGenres([]string{"Comedy", "Romance"}, movies)
+				expected = filterMovieExcludingGenres([]string{"Adventure"}, expected)
+
+				require.NoError(t, err)
+				assertMovies(t, expected, data)
+				assertMetadata(t, Metadata{
+					CurrentPage:  1,
+					PageSize:     20,
+					FirstPage:    1,
+					LastPage:     1,
+					TotalRecords: 7,
+				}, meta)
+			},
+		},
 		{
 			name:   "Filter by 'Title' with no results",
 			title:  noResultsTitleSearchTerm,
@@ -1050,7 +1168,12 @@ func TestMovieModelGetAll(t *testing.T) {
 		t.Run(
 			tc.name,
 			func(t *testing.T) {
-				data, meta, err := testModels.Movies.GetAll(tc.title, tc.genres, tc.filters)
+				data, meta, err := testModels.Movies.GetAll(MovieQuery{
+					Title:     tc.title,
+					Genres:    tc.genres,
+					GenresAny: tc.genresAny,
+					GenresNot: tc.genresNot,
+				}, tc.filters)
 
 				tc.assert(t, data, meta, err)
 			},
@@ -1094,13 +1217,43 @@ func TestMovieModelGet(t *testing.T) {
 	})
 }
 
+func TestMovieModelGetFields(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully return only the requested fields", func(t *testing.T) {
+		createdMovie := createRandomMovie(t, &testModels)
+
+		gotMovie, err := testModels.Movies.GetFields(createdMovie.ID, []string{"title"})
+
+		require.NoError(t, err)
+		require.Equal(t, createdMovie.ID, gotMovie.ID)
+		require.Equal(t, createdMovie.Title, gotMovie.Title)
+		require.Equal(t, createdMovie.Version, gotMovie.Version)
+		require.Zero(t, gotMovie.Year)
+		require.Zero(t, gotMovie.Runtime)
+		require.Nil(t, gotMovie.Genres)
+
+		movies, _, err := testModels.Movies.GetAll(MovieQuery{Fields: []string{"year"}}, Filters{Page: 1, PageSize: 20, Sort: "id", SortSafeList: MoviesSortSafeList})
+
+		require.NoError(t, err)
+		require.Len(t, movies, 1)
+		require.Equal(t, createdMovie.ID, movies[0].ID)
+		require.Equal(t, createdMovie.Year, movies[0].Year)
+		require.Empty(t, movies[0].Title)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+}
+
 func TestMovieModelDelete(t *testing.T) {
 	testModels := NewModels(testDB)
 
 	t.Run("Successfully delete a movie", func(t *testing.T) {
 		movie := createRandomMovie(t, &testModels)
 
-		err := testModels.Movies.Delete(movie.ID)
+		err := testModels.Movies.Delete(movie.ID, 0, 0)
 
 		require.NoError(t, err)
 
@@ -1109,24 +1262,343 @@ func TestMovieModelDelete(t *testing.T) {
 		require.Zero(t, gotMovie)
 		require.ErrorIs(t, err, ErrRecordNotFound)
 
+		trashed, metadata, err := testModels.Movies.GetAll(MovieQuery{Deleted: true}, Filters{Page: 1, PageSize: 20, Sort: "id", SortSafeList: MoviesSortSafeList})
+
+		require.NoError(t, err)
+		require.Equal(t, 1, metadata.TotalRecords)
+		require.Equal(t, movie.ID, trashed[0].ID)
+		require.NotNil(t, trashed[0].DeletedAt)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrRecordNotFound' when the movie is already deleted", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+
+		err := testModels.Movies.Delete(movie.ID, 0, 0)
+		require.NoError(t, err)
+
+		err = testModels.Movies.Delete(movie.ID, 0, 0)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Successfully delete the given version of a movie", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+
+		err := testModels.Movies.Delete(movie.ID, movie.Version, 0)
+		require.NoError(t, err)
+
+		_, err = testModels.Movies.Get(movie.ID)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrEditConflict' when the version does not match", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+
+		err := testModels.Movies.Delete(movie.ID, movie.Version+1, 0)
+		require.ErrorIs(t, err, ErrEditConflict)
+
+		_, err = testModels.Movies.Get(movie.ID)
+		require.NoError(t, err)
+
 		t.Cleanup(func() {
 			movieModelTestsTeardown(t)
 		})
 	})
 
 	t.Run("'ErrRecordNotFound' when given 'ID' does not exist", func(t *testing.T) {
-		err := testModels.Movies.Delete(gofakeit.Int64())
+		err := testModels.Movies.Delete(gofakeit.Int64(), 0, 0)
 
 		require.ErrorIs(t, err, ErrRecordNotFound)
 	})
 
 	t.Run("'ErrRecordNotFound' when given 'ID' is lower than 1", func(t *testing.T) {
-		err := testModels.Movies.Delete(0)
+		err := testModels.Movies.Delete(0, 0, 0)
 
 		require.ErrorIs(t, err, ErrRecordNotFound)
 	})
 }
 
+func TestMovieModelRestore(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully restore a deleted movie", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+
+		err := testModels.Movies.Delete(movie.ID, 0, 0)
+		require.NoError(t, err)
+
+		err = testModels.Movies.Restore(movie.ID)
+		require.NoError(t, err)
+
+		gotMovie, err := testModels.Movies.Get(movie.ID)
+
+		require.NoError(t, err)
+		require.Equal(t, movie.Title, gotMovie.Title)
+		require.Equal(t, movie.Version+2, gotMovie.Version)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrRecordNotFound' when the movie is not deleted", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+
+		err := testModels.Movies.Restore(movie.ID)
+
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrRecordNotFound' when given 'ID' is lower than 1", func(t *testing.T) {
+		err := testModels.Movies.Restore(0)
+
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	})
+}
+
+func TestMovieModelPurge(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Only purge movies deleted before the retention period", func(t *testing.T) {
+		oldMovie := createRandomMovie(t, &testModels)
+		recentMovie := createRandomMovie(t, &testModels)
+		liveMovie := createRandomMovie(t, &testModels)
+
+		poster := &Poster{
+			Original: "http://localhost/media/posters/1/a/original.jpg",
+			Keys:     []string{"posters/1/a/original.jpg"},
+		}
+
+		_, err := testModels.Movies.SetPoster(&oldMovie, poster, 0)
+		require.NoError(t, err)
+
+		require.NoError(t, testModels.Movies.Delete(oldMovie.ID, 0, 0))
+		require.NoError(t, testModels.Movies.Delete(recentMovie.ID, 0, 0))
+
+		_, err = testDB.Exec(`UPDATE movies SET deleted_at = NOW() - INTERVAL '31 days' WHERE id = $1`, oldMovie.ID)
+		require.NoError(t, err)
+
+		purged, posters, err := testModels.Movies.Purge(30 * 24 * time.Hour)
+
+		require.NoError(t, err)
+		require.Equal(t, int64(1), purged)
+		require.Equal(t, []*Poster{poster}, posters)
+
+		err = testModels.Movies.Restore(oldMovie.ID)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		err = testModels.Movies.Restore(recentMovie.ID)
+		require.NoError(t, err)
+
+		_, err = testModels.Movies.Get(liveMovie.ID)
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+}
+
+func TestMovieModelExport(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	filters := Filters{Sort: "-id", SortSafeList: MoviesSortSafeList}
+
+	t.Run("Successfully stream every matching movie", func(t *testing.T) {
+		movies := []Movie{
+			createRandomMovie(t, &testModels),
+			createRandomMovie(t, &testModels),
+			createRandomMovie(t, &testModels),
+		}
+
+		err := testModels.Movies.Delete(movies[1].ID, 0, 0)
+		require.NoError(t, err)
+
+		var gotIDs []int64
+
+		err = testModels.Movies.Export(context.Background(), MovieQuery{}, filters, func(movie *Movie) error {
+			gotIDs = append(gotIDs, movie.ID)
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, []int64{movies[2].ID, movies[0].ID}, gotIDs)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Stop when the callback returns an error", func(t *testing.T) {
+		createRandomMovie(t, &testModels)
+		createRandomMovie(t, &testModels)
+
+		errStop := errors.New("stop")
+		calls := 0
+
+		err := testModels.Movies.Export(context.Background(), MovieQuery{}, filters, func(movie *Movie) error {
+			calls++
+			return errStop
+		})
+
+		require.ErrorIs(t, err, errStop)
+		require.Equal(t, 1, calls)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+}
+
+func TestMovieModelBatch(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully apply every operation atomically", func(t *testing.T) {
+		toUpdate := createRandomMovie(t, &testModels)
+		toDelete := createRandomMovie(t, &testModels)
+
+		created := &Movie{Title: "Batch", Year: 2020, Runtime: 100, Genres: []string{"drama"}}
+		toUpdate.Title = "Updated"
+
+		errs, err := testModels.Movies.Batch([]MovieOperation{
+			{Action: MovieOperationCreate, Movie: created},
+			{Action: MovieOperationUpdate, Movie: &toUpdate},
+			{Action: MovieOperationDelete, Movie: &Movie{ID: toDelete.ID, Version: toDelete.Version}},
+		}, true, 0)
+
+		require.NoError(t, err)
+		require.Equal(t, []error{nil, nil, nil}, errs)
+		require.NotZero(t, created.ID)
+
+		gotMovie, err := testModels.Movies.Get(toUpdate.ID)
+		require.NoError(t, err)
+		require.Equal(t, "Updated", gotMovie.Title)
+
+		_, err = testModels.Movies.Get(toDelete.ID)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Roll back every operation when one fails in atomic mode", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+
+		created := &Movie{Title: "Batch", Year: 2020, Runtime: 100, Genres: []string{"drama"}}
+
+		errs, err := testModels.Movies.Batch([]MovieOperation{
+			{Action: MovieOperationCreate, Movie: created},
+			{Action: MovieOperationDelete, Movie: &Movie{ID: movie.ID, Version: movie.Version + 1}},
+		}, true, 0)
+
+		require.NoError(t, err)
+		require.ErrorIs(t, errs[0], ErrBatchAborted)
+		require.ErrorIs(t, errs[1], ErrEditConflict)
+
+		_, err = testModels.Movies.Get(created.ID)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		_, err = testModels.Movies.Get(movie.ID)
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Apply the remaining operations in best-effort mode", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+
+		created := &Movie{Title: "Batch", Year: 2020, Runtime: 100, Genres: []string{"drama"}}
+
+		errs, err := testModels.Movies.Batch([]MovieOperation{
+			{Action: MovieOperationDelete, Movie: &Movie{ID: gofakeit.Int64(), Version: 1}},
+			{Action: MovieOperationCreate, Movie: created},
+			{Action: MovieOperationDelete, Movie: &Movie{ID: movie.ID, Version: movie.Version}},
+		}, false, 0)
+
+		require.NoError(t, err)
+		require.ErrorIs(t, errs[0], ErrRecordNotFound)
+		require.NoError(t, errs[1])
+		require.NoError(t, errs[2])
+
+		_, err = testModels.Movies.Get(created.ID)
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+}
+
+func TestMovieModelSetPoster(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully replace and remove the poster", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+
+		poster := &Poster{
+			Original: "http://localhost/media/posters/1/a/original.jpg",
+			Small:    "http://localhost/media/posters/1/a/small.jpg",
+			Medium:   "http://localhost/media/posters/1/a/medium.jpg",
+			Keys:     []string{"posters/1/a/original.jpg", "posters/1/a/small.jpg", "posters/1/a/medium.jpg"},
+		}
+
+		previous, err := testModels.Movies.SetPoster(&movie, poster, 0)
+		require.NoError(t, err)
+		require.Nil(t, previous)
+		require.Equal(t, int32(2), movie.Version)
+
+		gotMovie, err := testModels.Movies.Get(movie.ID)
+		require.NoError(t, err)
+		require.Equal(t, poster, gotMovie.Poster)
+
+		previous, err = testModels.Movies.SetPoster(&movie, nil, 0)
+		require.NoError(t, err)
+		require.Equal(t, poster, previous)
+
+		gotMovie, err = testModels.Movies.Get(movie.ID)
+		require.NoError(t, err)
+		require.Nil(t, gotMovie.Poster)
+
+		revision, err := testModels.Revisions.Get(movie.ID, 2)
+		require.NoError(t, err)
+		require.Equal(t, RevisionActionUpdate, revision.Action)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrEditConflict' when version does not match", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		movie.Version++
+
+		_, err := testModels.Movies.SetPoster(&movie, nil, 0)
+		require.ErrorIs(t, err, ErrEditConflict)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+}
+
 func TestMovieModelUpdate(t *testing.T) {
 	testModels := NewModels(testDB)
 
@@ -1137,7 +1609,7 @@ func TestMovieModelUpdate(t *testing.T) {
 		movie.Runtime = movie.Runtime - Runtime(1)
 		movie.Year = movie.Year - 1
 
-		err := testModels.Movies.Update(&movie)
+		err := testModels.Movies.Update(&movie, 0)
 		require.NoError(t, err)
 
 		updatedMovie, err := testModels.Movies.Get(movie.ID)
@@ -1148,6 +1620,8 @@ func TestMovieModelUpdate(t *testing.T) {
 		require.Equal(t, movie.Year, updatedMovie.Year)
 		require.Equal(t, movie.Version, int32(2))
 		require.Equal(t, movie.Version, updatedMovie.Version)
+		require.False(t, movie.UpdatedAt.Before(movie.CreatedAt.Truncate(time.Second)))
+		require.WithinDuration(t, movie.UpdatedAt, updatedMovie.UpdatedAt, time.Second)
 
 		t.Cleanup(func() {
 			movieModelTestsTeardown(t)
@@ -1165,7 +1639,7 @@ func TestMovieModelUpdate(t *testing.T) {
 			CreatedAt: gofakeit.Date(),
 		}
 
-		err := testModels.Movies.Update(&movie)
+		err := testModels.Movies.Update(&movie, 0)
 		require.ErrorIs(t, err, ErrEditConflict)
 	})
 }
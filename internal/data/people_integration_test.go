@@ -0,0 +1,185 @@
+//go:build integration
+// +build integration
+
+package data
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomPerson it's a helper to populate the database
+// with people. It takes in a pointer of `testing.T` and
+// a pointer of `Models`, and returns a `Person` created
+// with fake random data.
+func createRandomPerson(t *testing.T, m *Models) Person {
+	person := Person{
+		Name:      gofakeit.Name(),
+		BirthYear: int32(gofakeit.Number(1900, 2000)),
+	}
+
+	err := m.People.Insert(&person)
+
+	require.NoError(t, err)
+
+	require.Equal(t, person.Version, int32(1))
+	require.NotZero(t, person.ID)
+	require.NotZero(t, person.CreatedAt)
+
+	return person
+}
+
+// peopleModelTestsTeardown it's a helper to truncate the `people`
+// table in the database during tests.
+func peopleModelTestsTeardown(t *testing.T) {
+	t.Helper()
+
+	query := `TRUNCATE TABLE people RESTART IDENTITY CASCADE`
+
+	_, err := testDB.Exec(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPersonModelInsert(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	createRandomPerson(t, &testModels)
+
+	t.Cleanup(func() {
+		peopleModelTestsTeardown(t)
+	})
+}
+
+func TestPersonModelGet(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully return person data", func(t *testing.T) {
+		createdPerson := createRandomPerson(t, &testModels)
+
+		gotPerson, err := testModels.People.Get(createdPerson.ID)
+
+		require.NoError(t, err)
+		require.Equal(t, createdPerson.Name, gotPerson.Name)
+		require.Equal(t, createdPerson.BirthYear, gotPerson.BirthYear)
+		require.Equal(t, createdPerson.Version, gotPerson.Version)
+
+		t.Cleanup(func() {
+			peopleModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Unknown birth year is returned as zero", func(t *testing.T) {
+		person := Person{Name: gofakeit.Name()}
+
+		err := testModels.People.Insert(&person)
+		require.NoError(t, err)
+
+		gotPerson, err := testModels.People.Get(person.ID)
+
+		require.NoError(t, err)
+		require.Zero(t, gotPerson.BirthYear)
+
+		t.Cleanup(func() {
+			peopleModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrRecordNotFound' when given 'ID' does not exist", func(t *testing.T) {
+		gotPerson, err := testModels.People.Get(gofakeit.Int64())
+
+		require.ErrorIs(t, err, ErrRecordNotFound)
+		require.Nil(t, gotPerson)
+	})
+}
+
+func TestPersonModelUpdate(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully update a person", func(t *testing.T) {
+		person := createRandomPerson(t, &testModels)
+		person.Name = gofakeit.Name()
+
+		err := testModels.People.Update(&person)
+
+		require.NoError(t, err)
+		require.Equal(t, int32(2), person.Version)
+
+		t.Cleanup(func() {
+			peopleModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrEditConflict' when version does not match", func(t *testing.T) {
+		person := createRandomPerson(t, &testModels)
+		person.Version++
+
+		err := testModels.People.Update(&person)
+
+		require.ErrorIs(t, err, ErrEditConflict)
+
+		t.Cleanup(func() {
+			peopleModelTestsTeardown(t)
+		})
+	})
+}
+
+func TestPersonModelDelete(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully delete a person", func(t *testing.T) {
+		person := createRandomPerson(t, &testModels)
+
+		err := testModels.People.Delete(person.ID)
+		require.NoError(t, err)
+
+		_, err = testModels.People.Get(person.ID)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	})
+
+	t.Run("'ErrRecordNotFound' when given 'ID' does not exist", func(t *testing.T) {
+		err := testModels.People.Delete(gofakeit.Int64())
+
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	})
+}
+
+func TestPersonModelGetAll(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	first := createRandomPerson(t, &testModels)
+	createRandomPerson(t, &testModels)
+
+	t.Run("Returns every person", func(t *testing.T) {
+		people, meta, err := testModels.People.GetAll("", Filters{
+			Page:         1,
+			PageSize:     20,
+			Sort:         "id",
+			SortSafeList: PeopleSortSafeList,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, people, 2)
+		require.Equal(t, 2, meta.TotalRecords)
+	})
+
+	t.Run("Filter by 'Name'", func(t *testing.T) {
+		people, _, err := testModels.People.GetAll(first.Name, Filters{
+			Page:         1,
+			PageSize:     20,
+			Sort:         "id",
+			SortSafeList: PeopleSortSafeList,
+		})
+
+		require.NoError(t, err)
+		require.NotEmpty(t, people)
+		require.Equal(t, first.ID, people[0].ID)
+	})
+
+	t.Cleanup(func() {
+		peopleModelTestsTeardown(t)
+	})
+}
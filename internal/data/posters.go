@@ -0,0 +1,117 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Poster holds the URLs of a movie poster and of its thumbnails. Keys are
+// the storage keys of those files, kept so they can be removed when the
+// poster is replaced.
+type Poster struct {
+	Original string   `json:"original"`
+	Small    string   `json:"small"`
+	Medium   string   `json:"medium"`
+	Keys     []string `json:"-"`
+}
+
+// posterRecord is how a Poster is stored in the movies.poster column.
+type posterRecord struct {
+	Original string   `json:"original"`
+	Small    string   `json:"small"`
+	Medium   string   `json:"medium"`
+	Keys     []string `json:"keys"`
+}
+
+func (p Poster) Value() (driver.Value, error) {
+	b, err := json.Marshal(posterRecord(p))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+func (p *Poster) Scan(src any) error {
+	var b []byte
+
+	switch v := src.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Poster", src)
+	}
+
+	var record posterRecord
+
+	err := json.Unmarshal(b, &record)
+	if err != nil {
+		return err
+	}
+
+	*p = Poster(record)
+
+	return nil
+}
+
+// SetPoster replaces the poster of the movie, a nil poster removes it. The
+// movie gets a new version, with its previous state and the acting user
+// recorded in the revision history, and ErrEditConflict is returned when it
+// is not at the given version anymore. The previous poster is returned so
+// its files can be cleaned up.
+func (m MovieModel) SetPoster(movie *Movie, poster *Poster, userID int64) (*Poster, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	err = insertMovieRevision(ctx, tx, movie.ID, movie.Version, RevisionActionUpdate, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+        UPDATE movies
+        SET poster = $1, updated_at = NOW(), version = version + 1
+        FROM (SELECT poster FROM movies WHERE id = $2) AS previous
+        WHERE movies.id = $2 AND movies.version = $3 AND movies.deleted_at IS NULL
+        RETURNING previous.poster, movies.version, movies.updated_at`
+
+	var previous *Poster
+
+	err = tx.QueryRowContext(ctx, query, poster, movie.ID, movie.Version).Scan(&previous, &movie.Version, &movie.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+
+	err = recordMovieEvent(ctx, tx, EventMovieUpdated, movie.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	movie.Poster = poster
+
+	return previous, nil
+}
@@ -0,0 +1,174 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/validator"
+)
+
+type Rating struct {
+	MovieID   int64     `json:"movieId"`
+	UserID    int64     `json:"-"`
+	Value     int       `json:"rating"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TODO: Test at handler level
+func ValidateRating(v *validator.Validator, rating *Rating) {
+	v.Check(rating.Value >= 1, "rating", "must be at least 1")
+	v.Check(rating.Value <= 10, "rating", "must not be more than 10")
+}
+
+type RatingModel struct {
+	DB *sql.DB
+}
+
+// Upsert creates or replaces the rating a user gave to a movie and
+// refreshes the denormalised average and count stored on the movie.
+func (m RatingModel) Upsert(rating *Rating) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	err = lockMovie(ctx, tx, rating.MovieID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO movie_ratings (movie_id, user_id, rating)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (movie_id, user_id)
+        DO UPDATE SET rating = EXCLUDED.rating, updated_at = NOW()
+        RETURNING created_at, updated_at`
+
+	args := []any{rating.MovieID, rating.UserID, rating.Value}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&rating.CreatedAt, &rating.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	err = refreshMovieRating(ctx, tx, rating.MovieID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes the rating a user gave to a movie and refreshes the
+// denormalised average and count stored on the movie.
+func (m RatingModel) Delete(movieID, userID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	err = lockMovie(ctx, tx, movieID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        DELETE FROM movie_ratings
+        WHERE movie_id = $1 AND user_id = $2`
+
+	result, err := tx.ExecContext(ctx, query, movieID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	err = refreshMovieRating(ctx, tx, movieID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m RatingModel) GetForUser(movieID, userID int64) (*Rating, error) {
+	query := `
+        SELECT movie_id, user_id, rating, created_at, updated_at
+        FROM movie_ratings
+        WHERE movie_id = $1 AND user_id = $2`
+
+	var rating Rating
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, movieID, userID).Scan(
+		&rating.MovieID,
+		&rating.UserID,
+		&rating.Value,
+		&rating.CreatedAt,
+		&rating.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &rating, nil
+}
+
+// lockMovie takes a row lock on the movie so concurrent rating changes
+// recompute the aggregates one after the other.
+func lockMovie(ctx context.Context, tx *sql.Tx, movieID int64) error {
+	var id int64
+
+	err := tx.QueryRowContext(ctx, `SELECT id FROM movies WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, movieID).Scan(&id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// refreshMovieRating recomputes the average and count stored on the movie,
+// giving it a new version as they are part of its representation.
+func refreshMovieRating(ctx context.Context, tx *sql.Tx, movieID int64) error {
+	query := `
+        UPDATE movies
+        SET rating = COALESCE((SELECT round(avg(rating), 2) FROM movie_ratings WHERE movie_id = $1), 0),
+        rating_count = (SELECT count(*) FROM movie_ratings WHERE movie_id = $1),
+        updated_at = NOW(), version = version + 1
+        WHERE id = $1`
+
+	_, err := tx.ExecContext(ctx, query, movieID)
+	return err
+}
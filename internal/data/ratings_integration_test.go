@@ -0,0 +1,116 @@
+//go:build integration
+// +build integration
+
+package data
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+)
+
+// ratingModelTestsTeardown it's a helper to truncate the `movies`
+// and `users` tables, and by cascade the ratings, during tests.
+func ratingModelTestsTeardown(t *testing.T) {
+	t.Helper()
+
+	movieModelTestsTeardown(t)
+	userModelTestsTeardown(t)
+}
+
+func TestRatingModelUpsert(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully rate a movie and refresh its aggregates", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		user1 := createRandomUser(t, &testModels)
+		user2 := createRandomUser(t, &testModels)
+
+		err := testModels.Ratings.Upsert(&Rating{MovieID: movie.ID, UserID: user1.ID, Value: 6})
+		require.NoError(t, err)
+
+		err = testModels.Ratings.Upsert(&Rating{MovieID: movie.ID, UserID: user2.ID, Value: 9})
+		require.NoError(t, err)
+
+		gotMovie, err := testModels.Movies.Get(movie.ID)
+		require.NoError(t, err)
+		require.Equal(t, 7.5, gotMovie.Rating)
+		require.Equal(t, int32(2), gotMovie.RatingCount)
+		require.Equal(t, movie.Version+2, gotMovie.Version, "every rating change gives the movie a new version")
+
+		t.Cleanup(func() {
+			ratingModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Successfully update an existing rating", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		user := createRandomUser(t, &testModels)
+
+		err := testModels.Ratings.Upsert(&Rating{MovieID: movie.ID, UserID: user.ID, Value: 3})
+		require.NoError(t, err)
+
+		err = testModels.Ratings.Upsert(&Rating{MovieID: movie.ID, UserID: user.ID, Value: 8})
+		require.NoError(t, err)
+
+		rating, err := testModels.Ratings.GetForUser(movie.ID, user.ID)
+		require.NoError(t, err)
+		require.Equal(t, 8, rating.Value)
+
+		gotMovie, err := testModels.Movies.Get(movie.ID)
+		require.NoError(t, err)
+		require.Equal(t, float64(8), gotMovie.Rating)
+		require.Equal(t, int32(1), gotMovie.RatingCount)
+
+		t.Cleanup(func() {
+			ratingModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrRecordNotFound' when movie does not exist", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+
+		err := testModels.Ratings.Upsert(&Rating{MovieID: gofakeit.Int64(), UserID: user.ID, Value: 5})
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		t.Cleanup(func() {
+			ratingModelTestsTeardown(t)
+		})
+	})
+}
+
+func TestRatingModelDelete(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully delete a rating and refresh aggregates", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		user := createRandomUser(t, &testModels)
+
+		err := testModels.Ratings.Upsert(&Rating{MovieID: movie.ID, UserID: user.ID, Value: 4})
+		require.NoError(t, err)
+
+		err = testModels.Ratings.Delete(movie.ID, user.ID)
+		require.NoError(t, err)
+
+		gotMovie, err := testModels.Movies.Get(movie.ID)
+		require.NoError(t, err)
+		require.Zero(t, gotMovie.Rating)
+		require.Zero(t, gotMovie.RatingCount)
+
+		t.Cleanup(func() {
+			ratingModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrRecordNotFound' when user has not rated the movie", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+
+		err := testModels.Ratings.Delete(movie.ID, gofakeit.Int64())
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		t.Cleanup(func() {
+			ratingModelTestsTeardown(t)
+		})
+	})
+}
@@ -0,0 +1,229 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/validator"
+)
+
+var ReviewsSortSafeList = []string{"id", "-id"}
+
+var ErrDuplicateReview = errors.New("duplicate review")
+
+type Review struct {
+	ID         int64     `json:"id"`
+	MovieID    int64     `json:"movieId"`
+	UserID     int64     `json:"-"`
+	AuthorName string    `json:"authorName"`
+	Title      string    `json:"title"`
+	Body       string    `json:"body"`
+	Hidden     bool      `json:"hidden"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	Version    int32     `json:"version"`
+}
+
+// TODO: Test at handler level
+func ValidateReview(v *validator.Validator, review *Review) {
+	v.Check(review.Title != "", "title", "must be provided")
+	v.Check(len(review.Title) <= 200, "title", "must not be more than 200 bytes long")
+
+	v.Check(review.Body != "", "body", "must be provided")
+	v.Check(len(review.Body) <= 10_000, "body", "must not be more than 10000 bytes long")
+}
+
+type ReviewModel struct {
+	DB *sql.DB
+}
+
+func (m ReviewModel) Insert(review *Review) error {
+	query := `
+        INSERT INTO reviews (movie_id, user_id, title, body)
+        SELECT id, $2, $3, $4
+        FROM movies
+        WHERE id = $1 AND deleted_at IS NULL
+        RETURNING id, created_at, updated_at, version`
+
+	args := []any{review.MovieID, review.UserID, review.Title, review.Body}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&review.ID, &review.CreatedAt, &review.UpdatedAt, &review.Version)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "reviews_movie_id_user_id_key"`:
+			return ErrDuplicateReview
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get returns the review of a movie, reporting ErrRecordNotFound while the
+// movie is in the trash.
+func (m ReviewModel) Get(movieID, id int64) (*Review, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+        SELECT reviews.id, reviews.movie_id, reviews.user_id, users.name, reviews.title, reviews.body,
+        reviews.hidden, reviews.created_at, reviews.updated_at, reviews.version
+        FROM reviews
+        INNER JOIN users ON users.id = reviews.user_id
+        INNER JOIN movies ON movies.id = reviews.movie_id
+        WHERE reviews.id = $1 AND reviews.movie_id = $2 AND movies.deleted_at IS NULL`
+
+	var review Review
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id, movieID).Scan(
+		&review.ID,
+		&review.MovieID,
+		&review.UserID,
+		&review.AuthorName,
+		&review.Title,
+		&review.Body,
+		&review.Hidden,
+		&review.CreatedAt,
+		&review.UpdatedAt,
+		&review.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &review, nil
+}
+
+// Update saves the review using the version for optimistic concurrency
+// control, in the same way MovieModel.Update does.
+func (m ReviewModel) Update(review *Review) error {
+	query := `
+        UPDATE reviews
+        SET title = $1, body = $2, hidden = $3, updated_at = NOW(), version = version + 1
+        WHERE id = $4 AND version = $5
+        RETURNING updated_at, version`
+
+	args := []any{review.Title, review.Body, review.Hidden, review.ID, review.Version}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&review.UpdatedAt, &review.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m ReviewModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+        DELETE FROM reviews
+        WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAllForMovie lists the reviews of a movie. Hidden reviews are only
+// returned when includeHidden is true, which is meant for moderators.
+func (m ReviewModel) GetAllForMovie(movieID int64, includeHidden bool, filters Filters) ([]Review, Metadata, error) {
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), reviews.id, reviews.movie_id, reviews.user_id, users.name, reviews.title,
+        reviews.body, reviews.hidden, reviews.created_at, reviews.updated_at, reviews.version
+        FROM reviews
+        INNER JOIN users ON users.id = reviews.user_id
+        WHERE reviews.movie_id = $1
+        AND (NOT reviews.hidden OR $2)
+        ORDER BY reviews.%s %s, reviews.id ASC
+        LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	args := []any{movieID, includeHidden, filters.limit(), filters.offsett()}
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	reviews := []Review{}
+
+	for rows.Next() {
+		var review Review
+
+		err := rows.Scan(
+			&totalRecords,
+			&review.ID,
+			&review.MovieID,
+			&review.UserID,
+			&review.AuthorName,
+			&review.Title,
+			&review.Body,
+			&review.Hidden,
+			&review.CreatedAt,
+			&review.UpdatedAt,
+			&review.Version,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return reviews, metadata, nil
+}
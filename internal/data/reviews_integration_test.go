@@ -0,0 +1,217 @@
+//go:build integration
+// +build integration
+
+package data
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomReview it's a helper to populate the database
+// with reviews. It takes in a pointer of `testing.T`, a pointer
+// of `Models` and the movie and user the review belongs to, and
+// returns a `Review` created with fake random data.
+func createRandomReview(t *testing.T, m *Models, movieID, userID int64) Review {
+	review := Review{
+		MovieID: movieID,
+		UserID:  userID,
+		Title:   gofakeit.Sentence(4),
+		Body:    gofakeit.Paragraph(1, 3, 10, " "),
+	}
+
+	err := m.Reviews.Insert(&review)
+
+	require.NoError(t, err)
+
+	require.Equal(t, review.Version, int32(1))
+	require.NotZero(t, review.ID)
+	require.NotZero(t, review.CreatedAt)
+	require.NotZero(t, review.UpdatedAt)
+
+	return review
+}
+
+// reviewModelTestsTeardown it's a helper to truncate the `movies`
+// and `users` tables, and by cascade the reviews, during tests.
+func reviewModelTestsTeardown(t *testing.T) {
+	t.Helper()
+
+	movieModelTestsTeardown(t)
+	userModelTestsTeardown(t)
+}
+
+func TestReviewModelInsert(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully insert a review", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		user := createRandomUser(t, &testModels)
+
+		createRandomReview(t, &testModels, movie.ID, user.ID)
+
+		t.Cleanup(func() {
+			reviewModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrDuplicateReview' when user already reviewed the movie", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		user := createRandomUser(t, &testModels)
+
+		createRandomReview(t, &testModels, movie.ID, user.ID)
+
+		err := testModels.Reviews.Insert(&Review{MovieID: movie.ID, UserID: user.ID, Title: "Again", Body: "Again"})
+		require.ErrorIs(t, err, ErrDuplicateReview)
+
+		t.Cleanup(func() {
+			reviewModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrRecordNotFound' when movie does not exist", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+
+		err := testModels.Reviews.Insert(&Review{MovieID: gofakeit.Int64(), UserID: user.ID, Title: "Title", Body: "Body"})
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		t.Cleanup(func() {
+			reviewModelTestsTeardown(t)
+		})
+	})
+}
+
+func TestReviewModelUpdate(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully update a review", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		user := createRandomUser(t, &testModels)
+		review := createRandomReview(t, &testModels, movie.ID, user.ID)
+
+		review.Body = gofakeit.Paragraph(1, 2, 10, " ")
+
+		err := testModels.Reviews.Update(&review)
+		require.NoError(t, err)
+		require.Equal(t, int32(2), review.Version)
+
+		gotReview, err := testModels.Reviews.Get(movie.ID, review.ID)
+		require.NoError(t, err)
+		require.Equal(t, review.Body, gotReview.Body)
+		require.Equal(t, user.Name, gotReview.AuthorName)
+
+		t.Cleanup(func() {
+			reviewModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrEditConflict' when version does not match", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		user := createRandomUser(t, &testModels)
+		review := createRandomReview(t, &testModels, movie.ID, user.ID)
+
+		review.Version++
+
+		err := testModels.Reviews.Update(&review)
+		require.ErrorIs(t, err, ErrEditConflict)
+
+		t.Cleanup(func() {
+			reviewModelTestsTeardown(t)
+		})
+	})
+}
+
+func TestReviewModelGet(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("'ErrRecordNotFound' when the movie is in the trash", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		user := createRandomUser(t, &testModels)
+		review := createRandomReview(t, &testModels, movie.ID, user.ID)
+
+		_, err := testModels.Reviews.Get(movie.ID, review.ID)
+		require.NoError(t, err)
+
+		err = testModels.Movies.Delete(movie.ID, 0, 0)
+		require.NoError(t, err)
+
+		_, err = testModels.Reviews.Get(movie.ID, review.ID)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		t.Cleanup(func() {
+			reviewModelTestsTeardown(t)
+		})
+	})
+}
+
+func TestReviewModelGetAllForMovie(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	movie := createRandomMovie(t, &testModels)
+	user1 := createRandomUser(t, &testModels)
+	user2 := createRandomUser(t, &testModels)
+
+	createRandomReview(t, &testModels, movie.ID, user1.ID)
+	hidden := createRandomReview(t, &testModels, movie.ID, user2.ID)
+
+	hidden.Hidden = true
+
+	err := testModels.Reviews.Update(&hidden)
+	require.NoError(t, err)
+
+	filters := Filters{
+		Page:         1,
+		PageSize:     20,
+		Sort:         "id",
+		SortSafeList: ReviewsSortSafeList,
+	}
+
+	t.Run("Hidden reviews are excluded", func(t *testing.T) {
+		reviews, meta, err := testModels.Reviews.GetAllForMovie(movie.ID, false, filters)
+
+		require.NoError(t, err)
+		require.Len(t, reviews, 1)
+		require.Equal(t, 1, meta.TotalRecords)
+		require.False(t, reviews[0].Hidden)
+	})
+
+	t.Run("Hidden reviews are included for moderators", func(t *testing.T) {
+		reviews, meta, err := testModels.Reviews.GetAllForMovie(movie.ID, true, filters)
+
+		require.NoError(t, err)
+		require.Len(t, reviews, 2)
+		require.Equal(t, 2, meta.TotalRecords)
+	})
+
+	t.Cleanup(func() {
+		reviewModelTestsTeardown(t)
+	})
+}
+
+func TestReviewModelDelete(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully delete a review", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		user := createRandomUser(t, &testModels)
+		review := createRandomReview(t, &testModels, movie.ID, user.ID)
+
+		err := testModels.Reviews.Delete(review.ID)
+		require.NoError(t, err)
+
+		_, err = testModels.Reviews.Get(movie.ID, review.ID)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		t.Cleanup(func() {
+			reviewModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrRecordNotFound' when given 'ID' does not exist", func(t *testing.T) {
+		err := testModels.Reviews.Delete(gofakeit.Int64())
+
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,166 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	RevisionActionUpdate = "update"
+	RevisionActionDelete = "delete"
+)
+
+var RevisionsSortSafeList = []string{"version", "-version"}
+
+// MovieRevision is a snapshot of a movie as it was right before an update
+// or a delete, together with the user who made the change.
+type MovieRevision struct {
+	ID        int64     `json:"id"`
+	MovieID   int64     `json:"movieId"`
+	Version   int32     `json:"version"`
+	Title     string    `json:"title"`
+	Year      int32     `json:"year"`
+	Runtime   Runtime   `json:"runtime"`
+	Genres    []string  `json:"genres"`
+	Action    string    `json:"action"`
+	UserID    *int64    `json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// insertMovieRevision copies the current state of the movie into the
+// revisions table. It only copies the row when it is still at the expected
+// version, returning ErrEditConflict otherwise.
+func insertMovieRevision(ctx context.Context, tx *sql.Tx, movieID int64, version int32, action string, userID int64) error {
+	query := `
+        INSERT INTO movie_revisions (movie_id, version, title, year, runtime, genres, action, user_id)
+        SELECT id, version, title, year, runtime, genres, $3, NULLIF($4, 0)
+        FROM movies
+        WHERE id = $1 AND version = $2 AND deleted_at IS NULL
+        FOR UPDATE`
+
+	result, err := tx.ExecContext(ctx, query, movieID, version, action, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+type RevisionModel struct {
+	DB *sql.DB
+}
+
+func (m RevisionModel) Get(movieID int64, version int32) (*MovieRevision, error) {
+	query := `
+        SELECT id, movie_id, version, title, year, runtime, genres, action, user_id, created_at
+        FROM movie_revisions
+        WHERE movie_id = $1 AND version = $2`
+
+	var revision MovieRevision
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, movieID, version).Scan(
+		&revision.ID,
+		&revision.MovieID,
+		&revision.Version,
+		&revision.Title,
+		&revision.Year,
+		&revision.Runtime,
+		pq.Array(&revision.Genres),
+		&revision.Action,
+		&revision.UserID,
+		&revision.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &revision, nil
+}
+
+// GetAllForMovie returns the revisions of a movie, in the trash or not. It
+// reports ErrRecordNotFound when the movie does not exist anymore.
+func (m RevisionModel) GetAllForMovie(movieID int64, filters Filters) ([]MovieRevision, Metadata, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var exists bool
+
+	err := m.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM movies WHERE id = $1)`, movieID).Scan(&exists)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	if !exists {
+		return nil, Metadata{}, ErrRecordNotFound
+	}
+
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, movie_id, version, title, year, runtime, genres, action, user_id, created_at
+        FROM movie_revisions
+        WHERE movie_id = $1
+        ORDER BY %s %s, id ASC
+        LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
+
+	rows, err := m.DB.QueryContext(ctx, query, movieID, filters.limit(), filters.offsett())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	revisions := []MovieRevision{}
+
+	for rows.Next() {
+		var revision MovieRevision
+
+		err := rows.Scan(
+			&totalRecords,
+			&revision.ID,
+			&revision.MovieID,
+			&revision.Version,
+			&revision.Title,
+			&revision.Year,
+			&revision.Runtime,
+			pq.Array(&revision.Genres),
+			&revision.Action,
+			&revision.UserID,
+			&revision.CreatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return revisions, metadata, nil
+}
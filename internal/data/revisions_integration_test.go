@@ -0,0 +1,89 @@
+//go:build integration
+// +build integration
+
+package data
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMovieRevisions(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	filters := Filters{
+		Page:         1,
+		PageSize:     20,
+		Sort:         "-version",
+		SortSafeList: RevisionsSortSafeList,
+	}
+
+	t.Run("Update keeps the previous state and acting user", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+		movie := createRandomMovie(t, &testModels)
+		originalTitle := movie.Title
+
+		movie.Title = "New Title"
+
+		err := testModels.Movies.Update(&movie, user.ID)
+		require.NoError(t, err)
+
+		revision, err := testModels.Revisions.Get(movie.ID, 1)
+		require.NoError(t, err)
+		require.Equal(t, originalTitle, revision.Title)
+		require.Equal(t, RevisionActionUpdate, revision.Action)
+		require.NotNil(t, revision.UserID)
+		require.Equal(t, user.ID, *revision.UserID)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+			userModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Failed update does not record a revision", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		movie.Version++
+
+		err := testModels.Movies.Update(&movie, 0)
+		require.ErrorIs(t, err, ErrEditConflict)
+
+		revisions, _, err := testModels.Revisions.GetAllForMovie(movie.ID, filters)
+		require.NoError(t, err)
+		require.Empty(t, revisions)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Delete keeps the last state", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+
+		movie.Title = "Second Title"
+
+		err := testModels.Movies.Update(&movie, 0)
+		require.NoError(t, err)
+
+		err = testModels.Movies.Delete(movie.ID, 0, 0)
+		require.NoError(t, err)
+
+		revisions, meta, err := testModels.Revisions.GetAllForMovie(movie.ID, filters)
+		require.NoError(t, err)
+		require.Len(t, revisions, 2)
+		require.Equal(t, 2, meta.TotalRecords)
+		require.Equal(t, RevisionActionDelete, revisions[0].Action)
+		require.Equal(t, "Second Title", revisions[0].Title)
+		require.Nil(t, revisions[0].UserID)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+	t.Run("'ErrRecordNotFound' when the movie does not exist", func(t *testing.T) {
+		_, _, err := testModels.Revisions.GetAllForMovie(gofakeit.Int64(), filters)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	})
+}
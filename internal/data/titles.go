@@ -0,0 +1,235 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/validator"
+	"github.com/lib/pq"
+)
+
+// LanguageTagRX matches the language tags accepted for alternate titles:
+// a language, optionally followed by a script and a region, such as "pt",
+// "pt-BR" or "zh-Hant-TW".
+var LanguageTagRX = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z]{4})?(-[a-zA-Z]{2}|-[0-9]{3})?$`)
+
+// movieTitlesColumn selects the alternate titles of the movies row as a
+// JSON object keyed by language tag, to be scanned into Titles.
+const movieTitlesColumn = `COALESCE((
+            SELECT jsonb_object_agg(movie_titles.language, movie_titles.title)
+            FROM movie_titles
+            WHERE movie_titles.movie_id = movies.id
+        ), '{}')`
+
+// Titles holds the alternate titles of a movie keyed by language tag.
+type Titles map[string]string
+
+func (t *Titles) Scan(src any) error {
+	var b []byte
+
+	switch v := src.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Titles", src)
+	}
+
+	return json.Unmarshal(b, t)
+}
+
+// Normalize returns the titles keyed by their canonical language tags.
+func (t Titles) Normalize() Titles {
+	titles := make(Titles, len(t))
+
+	for tag, title := range t {
+		titles[CanonicalLanguageTag(tag)] = strings.TrimSpace(title)
+	}
+
+	return titles
+}
+
+// Lookup returns the title that best matches the languages, given in order
+// of preference. For each language an exact match is preferred, then the
+// title for its base language, then the title for any region of it. A "*"
+// matches the original title, which is reported as not found.
+func (t Titles) Lookup(languages []string) (string, bool) {
+	tags := make([]string, 0, len(t))
+
+	for tag := range t {
+		tags = append(tags, tag)
+	}
+
+	sort.Strings(tags)
+
+	for _, language := range languages {
+		if language == "*" {
+			return "", false
+		}
+
+		if title, ok := t[language]; ok {
+			return title, true
+		}
+
+		base, _, _ := strings.Cut(language, "-")
+
+		if title, ok := t[base]; ok {
+			return title, true
+		}
+
+		for _, tag := range tags {
+			if strings.HasPrefix(tag, base+"-") {
+				return t[tag], true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// CanonicalLanguageTag formats a language tag with a lowercase language,
+// a titlecase script and an uppercase region, so "PT-br" becomes "pt-BR".
+func CanonicalLanguageTag(tag string) string {
+	subtags := strings.Split(strings.TrimSpace(tag), "-")
+
+	for i, subtag := range subtags {
+		switch {
+		case i == 0:
+			subtags[i] = strings.ToLower(subtag)
+		case len(subtag) == 4:
+			subtags[i] = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+		default:
+			subtags[i] = strings.ToUpper(subtag)
+		}
+	}
+
+	return strings.Join(subtags, "-")
+}
+
+// ParseAcceptLanguage returns the canonical language tags of an
+// Accept-Language header in order of preference. Tags with a zero or
+// invalid quality, and tags that are not valid, are left out.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	var ranges []weighted
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+
+		quality := 1.0
+
+		if params != "" {
+			value, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+			if !ok {
+				continue
+			}
+
+			var err error
+
+			quality, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+
+		if quality == 0 || (tag != "*" && !LanguageTagRX.MatchString(tag)) {
+			continue
+		}
+
+		ranges = append(ranges, weighted{tag: CanonicalLanguageTag(tag), quality: quality})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	languages := make([]string, len(ranges))
+
+	for i := range ranges {
+		languages[i] = ranges[i].tag
+	}
+
+	return languages
+}
+
+// TODO: Test at handler level
+func ValidateTitles(v *validator.Validator, titles Titles) {
+	v.Check(titles != nil, "titles", "must be provided")
+	v.Check(len(titles) <= 50, "titles", "must not contain more than 50 titles")
+
+	seen := make(map[string]bool)
+
+	for tag, title := range titles {
+		key := "titles." + tag
+
+		v.Check(LanguageTagRX.MatchString(tag), key, "must be keyed by a valid language tag")
+		v.Check(strings.TrimSpace(title) != "", key, "must be provided")
+		v.Check(len(title) <= 500, key, "must not be more than 500 bytes long")
+
+		canonical := CanonicalLanguageTag(tag)
+		v.Check(!seen[canonical], "titles", "must not repeat the same language")
+		seen[canonical] = true
+	}
+}
+
+type TitleModel struct {
+	DB *sql.DB
+}
+
+// ReplaceForMovie swaps all alternate titles of a movie for the given ones
+// in a single transaction. The movie gets a new version, and ErrEditConflict
+// is returned when it is not at the given version anymore.
+func (m TitleModel) ReplaceForMovie(movie *Movie, titles Titles) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	err = touchMovie(ctx, tx, movie)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM movie_titles WHERE movie_id = $1`, movie.ID)
+	if err != nil {
+		return err
+	}
+
+	languages := make([]string, 0, len(titles))
+	values := make([]string, 0, len(titles))
+
+	for language, title := range titles {
+		languages = append(languages, language)
+		values = append(values, title)
+	}
+
+	query := `
+        INSERT INTO movie_titles (movie_id, language, title)
+        SELECT $1, language, title
+        FROM unnest($2::text[], $3::text[]) AS t(language, title)`
+
+	_, err = tx.ExecContext(ctx, query, movie.ID, pq.Array(languages), pq.Array(values))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
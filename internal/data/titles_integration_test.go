@@ -0,0 +1,72 @@
+//go:build integration
+// +build integration
+
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTitleModelReplaceForMovie(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully replace the alternate titles of a movie", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+
+		err := testModels.Titles.ReplaceForMovie(&movie, Titles{
+			"de":    "Zurück in die Zukunft",
+			"pt-BR": "De Volta para o Futuro",
+		})
+		require.NoError(t, err)
+
+		got, err := testModels.Movies.Get(movie.ID)
+		require.NoError(t, err)
+		require.Equal(t, Titles{"de": "Zurück in die Zukunft", "pt-BR": "De Volta para o Futuro"}, got.Titles)
+
+		err = testModels.Titles.ReplaceForMovie(&movie, Titles{})
+		require.NoError(t, err)
+
+		got, err = testModels.Movies.Get(movie.ID)
+		require.NoError(t, err)
+		require.Empty(t, got.Titles)
+		require.Equal(t, int32(3), got.Version)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrEditConflict' when version does not match", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		movie.Version++
+
+		err := testModels.Titles.ReplaceForMovie(&movie, Titles{})
+		require.ErrorIs(t, err, ErrEditConflict)
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Search movies by their alternate titles", func(t *testing.T) {
+		movie := createRandomMovie(t, &testModels)
+		createRandomMovie(t, &testModels)
+
+		err := testModels.Titles.ReplaceForMovie(&movie, Titles{"de": "Zurück in die Zukunft"})
+		require.NoError(t, err)
+
+		filters := Filters{Page: 1, PageSize: 20, Sort: "id", SortSafeList: MoviesSortSafeList}
+
+		movies, metadata, err := testModels.Movies.GetAll(MovieQuery{Title: "zukunft"}, filters)
+		require.NoError(t, err)
+		require.Equal(t, 1, metadata.TotalRecords)
+		require.Equal(t, movie.ID, movies[0].ID)
+		require.Equal(t, "Zurück in die Zukunft", movies[0].Titles["de"])
+
+		t.Cleanup(func() {
+			movieModelTestsTeardown(t)
+		})
+	})
+}
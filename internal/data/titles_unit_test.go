@@ -0,0 +1,86 @@
+//go:build unit
+// +build unit
+
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testTitles = Titles{
+	"de":    "Zurück in die Zukunft",
+	"pt-BR": "De Volta para o Futuro",
+	"pt-PT": "Regresso ao Futuro",
+}
+
+func TestTitlesLookup(t *testing.T) {
+	testCases := []struct {
+		name      string
+		languages []string
+		expected  string
+		found     bool
+	}{
+		{name: "Exact match", languages: []string{"pt-PT"}, expected: "Regresso ao Futuro", found: true},
+		{name: "Base language of a region", languages: []string{"de-AT"}, expected: "Zurück in die Zukunft", found: true},
+		{name: "Any region of a base language", languages: []string{"pt"}, expected: "De Volta para o Futuro", found: true},
+		{name: "First matching preference", languages: []string{"fr", "de"}, expected: "Zurück in die Zukunft", found: true},
+		{name: "Wildcard before a match", languages: []string{"*", "de"}, expected: "", found: false},
+		{name: "No match", languages: []string{"fr"}, expected: "", found: false},
+		{name: "No languages", languages: nil, expected: "", found: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				title, found := testTitles.Lookup(tc.languages)
+
+				require.Equal(t, tc.found, found)
+				require.Equal(t, tc.expected, title)
+			},
+		)
+	}
+}
+
+func TestMovieLocalize(t *testing.T) {
+	movie := Movie{Title: "Back to the Future", Titles: testTitles}
+
+	movie.Localize([]string{"de"})
+	require.Equal(t, "Zurück in die Zukunft", movie.LocalizedTitle)
+
+	movie.Localize([]string{"fr"})
+	require.Equal(t, "Back to the Future", movie.LocalizedTitle)
+}
+
+func TestCanonicalLanguageTag(t *testing.T) {
+	require.Equal(t, "pt-BR", CanonicalLanguageTag("PT-br"))
+	require.Equal(t, "zh-Hant-TW", CanonicalLanguageTag("zh-hant-tw"))
+	require.Equal(t, "es-419", CanonicalLanguageTag(" es-419 "))
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   string
+		expected []string
+	}{
+		{name: "Empty header", header: "", expected: []string{}},
+		{name: "Single language", header: "pt-br", expected: []string{"pt-BR"}},
+		{name: "Ordered by quality", header: "en;q=0.5, pt-BR, de;q=0.8", expected: []string{"pt-BR", "de", "en"}},
+		{name: "Equal qualities keep their order", header: "fr;q=0.7, de;q=0.7", expected: []string{"fr", "de"}},
+		{name: "Wildcard", header: "de, *;q=0.1", expected: []string{"de", "*"}},
+		{name: "Zero and invalid qualities are skipped", header: "de;q=0, fr;q=2, es;q=abc, it", expected: []string{"it"}},
+		{name: "Invalid tags are skipped", header: "not a tag, en", expected: []string{"en"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				require.Equal(t, tc.expected, ParseAcceptLanguage(tc.header))
+			},
+		)
+	}
+}
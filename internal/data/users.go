@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"time"
 
@@ -177,6 +178,57 @@ func (m UserModel) Update(user *User) error {
 	return nil
 }
 
+// Activate activates the user and deletes its activation tokens, queueing
+// the user.activated event for the subscribed webhooks in the same
+// transaction. The event only carries the user ID, subscribers are not
+// entitled to the rest of the account.
+func (m UserModel) Activate(user *User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+        UPDATE users
+        SET activated = true, version = version + 1
+        WHERE id = $1 AND version = $2
+        RETURNING version`
+
+	err = tx.QueryRowContext(ctx, query, user.ID, user.Version).Scan(&user.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	user.Activated = true
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM tokens WHERE scope = $1 AND user_id = $2`, ScopeActivation, user.ID)
+	if err != nil {
+		return err
+	}
+
+	js, err := json.Marshal(map[string]int64{"id": user.ID})
+	if err != nil {
+		return err
+	}
+
+	err = enqueueDeliveries(ctx, tx, EventUserActivated, js)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (m UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error) {
 	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
 
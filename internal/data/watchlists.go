@@ -0,0 +1,246 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/validator"
+	"github.com/lib/pq"
+)
+
+var (
+	WatchlistSortSafeList = []string{"added_at", "title", "year", "-added_at", "-title", "-year"}
+	WatchedSortSafeList   = []string{"watched_on", "title", "year", "-watched_on", "-title", "-year"}
+)
+
+type WatchlistItem struct {
+	Movie   Movie     `json:"movie"`
+	AddedAt time.Time `json:"addedAt"`
+}
+
+type WatchedItem struct {
+	Movie     Movie     `json:"movie"`
+	WatchedOn string    `json:"watchedOn"`
+	AddedAt   time.Time `json:"addedAt"`
+}
+
+// TODO: Test at handler level
+func ValidateWatchedOn(v *validator.Validator, watchedOn string) {
+	date, err := time.Parse(time.DateOnly, watchedOn)
+
+	v.Check(err == nil, "watchedOn", "must be a date in the YYYY-MM-DD format")
+	v.Check(err != nil || !date.After(time.Now()), "watchedOn", "must not be in the future")
+}
+
+type WatchlistModel struct {
+	DB *sql.DB
+}
+
+// Add puts a movie in the user's watchlist. Adding a movie twice is not an
+// error, the original item is kept.
+func (m WatchlistModel) Add(userID, movieID int64) (*WatchlistItem, error) {
+	query := `
+        INSERT INTO watchlist_items (user_id, movie_id)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id, movie_id) DO UPDATE SET user_id = EXCLUDED.user_id
+        RETURNING added_at`
+
+	item := WatchlistItem{Movie: Movie{ID: movieID}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, userID, movieID).Scan(&item.AddedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: insert or update on table "watchlist_items" violates foreign key constraint "watchlist_items_movie_id_fkey"`:
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &item, nil
+}
+
+func (m WatchlistModel) Remove(userID, movieID int64) error {
+	return removeUserMovieListItem(m.DB, "watchlist_items", userID, movieID)
+}
+
+func (m WatchlistModel) GetAll(userID int64, filters Filters) ([]WatchlistItem, Metadata, error) {
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), movies.id, movies.created_at, movies.title, movies.year, movies.runtime,
+        movies.genres, movies.rating, movies.rating_count, movies.version, movies.poster, watchlist_items.added_at
+        FROM watchlist_items
+        INNER JOIN movies ON movies.id = watchlist_items.movie_id
+        WHERE watchlist_items.user_id = $1
+        AND movies.deleted_at IS NULL
+        ORDER BY %s %s, movies.id ASC
+        LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, filters.limit(), filters.offsett())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	items := []WatchlistItem{}
+
+	for rows.Next() {
+		var item WatchlistItem
+
+		err := rows.Scan(
+			&totalRecords,
+			&item.Movie.ID,
+			&item.Movie.CreatedAt,
+			&item.Movie.Title,
+			&item.Movie.Year,
+			&item.Movie.Runtime,
+			pq.Array(&item.Movie.Genres),
+			&item.Movie.Rating,
+			&item.Movie.RatingCount,
+			&item.Movie.Version,
+			&item.Movie.Poster,
+			&item.AddedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return items, metadata, nil
+}
+
+type WatchedModel struct {
+	DB *sql.DB
+}
+
+// Upsert records that the user watched a movie on the given date, replacing
+// the date of a previous entry for the same movie.
+func (m WatchedModel) Upsert(userID, movieID int64, watchedOn string) (*WatchedItem, error) {
+	query := `
+        INSERT INTO watched_items (user_id, movie_id, watched_on)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id, movie_id) DO UPDATE SET watched_on = EXCLUDED.watched_on
+        RETURNING to_char(watched_on, 'YYYY-MM-DD'), added_at`
+
+	item := WatchedItem{Movie: Movie{ID: movieID}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, userID, movieID, watchedOn).Scan(&item.WatchedOn, &item.AddedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: insert or update on table "watched_items" violates foreign key constraint "watched_items_movie_id_fkey"`:
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &item, nil
+}
+
+func (m WatchedModel) Remove(userID, movieID int64) error {
+	return removeUserMovieListItem(m.DB, "watched_items", userID, movieID)
+}
+
+func (m WatchedModel) GetAll(userID int64, filters Filters) ([]WatchedItem, Metadata, error) {
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), movies.id, movies.created_at, movies.title, movies.year, movies.runtime,
+        movies.genres, movies.rating, movies.rating_count, movies.version, movies.poster,
+        to_char(watched_items.watched_on, 'YYYY-MM-DD'), watched_items.added_at
+        FROM watched_items
+        INNER JOIN movies ON movies.id = watched_items.movie_id
+        WHERE watched_items.user_id = $1
+        AND movies.deleted_at IS NULL
+        ORDER BY %s %s, movies.id ASC
+        LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, filters.limit(), filters.offsett())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	items := []WatchedItem{}
+
+	for rows.Next() {
+		var item WatchedItem
+
+		err := rows.Scan(
+			&totalRecords,
+			&item.Movie.ID,
+			&item.Movie.CreatedAt,
+			&item.Movie.Title,
+			&item.Movie.Year,
+			&item.Movie.Runtime,
+			pq.Array(&item.Movie.Genres),
+			&item.Movie.Rating,
+			&item.Movie.RatingCount,
+			&item.Movie.Version,
+			&item.Movie.Poster,
+			&item.WatchedOn,
+			&item.AddedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return items, metadata, nil
+}
+
+func removeUserMovieListItem(db *sql.DB, table string, userID, movieID int64) error {
+	query := fmt.Sprintf(`
+        DELETE FROM %s
+        WHERE user_id = $1 AND movie_id = $2`, table)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := db.ExecContext(ctx, query, userID, movieID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+//go:build integration
+// +build integration
+
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+)
+
+// watchlistModelTestsTeardown it's a helper to truncate the `movies`
+// and `users` tables, and by cascade the watchlists, during tests.
+func watchlistModelTestsTeardown(t *testing.T) {
+	t.Helper()
+
+	movieModelTestsTeardown(t)
+	userModelTestsTeardown(t)
+}
+
+func TestWatchlistModel(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	filters := Filters{
+		Page:         1,
+		PageSize:     20,
+		Sort:         "-added_at",
+		SortSafeList: WatchlistSortSafeList,
+	}
+
+	t.Run("Successfully add, list and remove movies", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+		movie1 := createRandomMovie(t, &testModels)
+		movie2 := createRandomMovie(t, &testModels)
+
+		_, err := testModels.Watchlist.Add(user.ID, movie1.ID)
+		require.NoError(t, err)
+
+		_, err = testModels.Watchlist.Add(user.ID, movie2.ID)
+		require.NoError(t, err)
+
+		_, err = testModels.Watchlist.Add(user.ID, movie2.ID)
+		require.NoError(t, err)
+
+		items, meta, err := testModels.Watchlist.GetAll(user.ID, filters)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Equal(t, 2, meta.TotalRecords)
+
+		movies, _, err := testModels.Movies.GetAll(MovieQuery{InWatchlistOf: user.ID}, Filters{
+			Page:         1,
+			PageSize:     20,
+			Sort:         "id",
+			SortSafeList: MoviesSortSafeList,
+		})
+		require.NoError(t, err)
+		require.Len(t, movies, 2)
+
+		err = testModels.Watchlist.Remove(user.ID, movie1.ID)
+		require.NoError(t, err)
+
+		items, _, err = testModels.Watchlist.GetAll(user.ID, filters)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		require.Equal(t, movie2.ID, items[0].Movie.ID)
+
+		t.Cleanup(func() {
+			watchlistModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("'ErrRecordNotFound' when movie does not exist", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+
+		_, err := testModels.Watchlist.Add(user.ID, gofakeit.Int64())
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		err = testModels.Watchlist.Remove(user.ID, gofakeit.Int64())
+		require.ErrorIs(t, err, ErrRecordNotFound)
+
+		t.Cleanup(func() {
+			watchlistModelTestsTeardown(t)
+		})
+	})
+}
+
+func TestWatchedModel(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Successfully record and update watched date", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+		movie := createRandomMovie(t, &testModels)
+
+		item, err := testModels.Watched.Upsert(user.ID, movie.ID, "2020-01-02")
+		require.NoError(t, err)
+		require.Equal(t, "2020-01-02", item.WatchedOn)
+
+		today := time.Now().Format(time.DateOnly)
+
+		item, err = testModels.Watched.Upsert(user.ID, movie.ID, today)
+		require.NoError(t, err)
+		require.Equal(t, today, item.WatchedOn)
+
+		items, meta, err := testModels.Watched.GetAll(user.ID, Filters{
+			Page:         1,
+			PageSize:     20,
+			Sort:         "-watched_on",
+			SortSafeList: WatchedSortSafeList,
+		})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		require.Equal(t, 1, meta.TotalRecords)
+		require.Equal(t, today, items[0].WatchedOn)
+
+		err = testModels.Watched.Remove(user.ID, movie.ID)
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			watchlistModelTestsTeardown(t)
+		})
+	})
+}
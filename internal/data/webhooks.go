@@ -0,0 +1,448 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/brGuirra/greenlight/internal/validator"
+	"github.com/lib/pq"
+)
+
+const (
+	EventMovieCreated  = "movie.created"
+	EventMovieUpdated  = "movie.updated"
+	EventMovieDeleted  = "movie.deleted"
+	EventUserActivated = "user.activated"
+
+	// EventPing is only sent on request, to test a webhook, and cannot be
+	// subscribed to.
+	EventPing = "ping"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+var (
+	WebhookEvents = []string{EventMovieCreated, EventMovieUpdated, EventMovieDeleted, EventUserActivated}
+
+	WebhookDeliveriesSortSafeList = []string{"id", "-id"}
+	WebhookDeliveryStatuses       = []string{DeliveryPending, DeliveryDelivered, DeliveryFailed}
+)
+
+type Webhook struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"-"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	Version   int32     `json:"version"`
+}
+
+// WebhookDelivery is a single event queued for a webhook. Deliveries stay
+// pending, retried with a growing delay, until the subscriber accepts them
+// or they run out of attempts and are marked failed.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhookId"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"nextAttemptAt"`
+	LastStatusCode int             `json:"lastStatusCode,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+}
+
+// DueDelivery is a delivery claimed for sending, along with the address
+// and secret of its webhook.
+type DueDelivery struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
+
+// TODO: Test at handler level
+func ValidateWebhook(v *validator.Validator, webhook *Webhook) {
+	v.Check(webhook.URL != "", "url", "must be provided")
+	v.Check(len(webhook.URL) <= 2_048, "url", "must not be more than 2048 bytes long")
+
+	if webhook.URL != "" {
+		u, err := url.Parse(webhook.URL)
+		v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "url", "must be an absolute http or https URL")
+	}
+
+	v.Check(len(webhook.Secret) >= 16, "secret", "must be at least 16 bytes long")
+	v.Check(len(webhook.Secret) <= 255, "secret", "must not be more than 255 bytes long")
+
+	v.Check(len(webhook.Events) > 0, "events", "must contain at least 1 event")
+	v.Check(validator.Unique(webhook.Events), "events", "must not contain duplicate values")
+
+	for _, event := range webhook.Events {
+		if !validator.PermittedValue(event, WebhookEvents...) {
+			v.AddError("events", "must only contain movie.created, movie.updated, movie.deleted or user.activated")
+			break
+		}
+	}
+}
+
+type WebhookModel struct {
+	DB *sql.DB
+}
+
+func (m WebhookModel) Insert(webhook *Webhook) error {
+	query := `
+        INSERT INTO webhooks (user_id, url, secret, events, active)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at, version`
+
+	args := []any{webhook.UserID, webhook.URL, webhook.Secret, pq.Array(webhook.Events), webhook.Active}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&webhook.ID, &webhook.CreatedAt, &webhook.Version)
+}
+
+func (m WebhookModel) Get(id int64) (*Webhook, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+        SELECT id, user_id, url, secret, events, active, created_at, version
+        FROM webhooks
+        WHERE id = $1`
+
+	var webhook Webhook
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&webhook.ID,
+		&webhook.UserID,
+		&webhook.URL,
+		&webhook.Secret,
+		pq.Array(&webhook.Events),
+		&webhook.Active,
+		&webhook.CreatedAt,
+		&webhook.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &webhook, nil
+}
+
+func (m WebhookModel) GetAllForUser(userID int64) ([]Webhook, error) {
+	query := `
+        SELECT id, user_id, url, secret, events, active, created_at, version
+        FROM webhooks
+        WHERE user_id = $1
+        ORDER BY id ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+
+	for rows.Next() {
+		var webhook Webhook
+
+		err := rows.Scan(
+			&webhook.ID,
+			&webhook.UserID,
+			&webhook.URL,
+			&webhook.Secret,
+			pq.Array(&webhook.Events),
+			&webhook.Active,
+			&webhook.CreatedAt,
+			&webhook.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (m WebhookModel) Update(webhook *Webhook) error {
+	query := `
+        UPDATE webhooks
+        SET url = $1, secret = $2, events = $3, active = $4, version = version + 1
+        WHERE id = $5 AND version = $6
+        RETURNING version`
+
+	args := []any{webhook.URL, webhook.Secret, pq.Array(webhook.Events), webhook.Active, webhook.ID, webhook.Version}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&webhook.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m WebhookModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+        DELETE FROM webhooks
+        WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+type WebhookDeliveryModel struct {
+	DB *sql.DB
+}
+
+// enqueueDeliveries queues the event for every active webhook subscribed to
+// it, within the transaction of the change it reports, so that it is queued
+// if and only if that change is committed.
+func enqueueDeliveries(ctx context.Context, tx *sql.Tx, event string, payload json.RawMessage) error {
+	query := `
+        INSERT INTO webhook_deliveries (webhook_id, event, payload)
+        SELECT id, $1, $2
+        FROM webhooks
+        WHERE active AND $1 = ANY(events)`
+
+	_, err := tx.ExecContext(ctx, query, event, payload)
+	return err
+}
+
+// EnqueueFor queues the event for a single webhook, whatever it is
+// subscribed to.
+func (m WebhookDeliveryModel) EnqueueFor(webhookID int64, event string, payload any) (*WebhookDelivery, error) {
+	js, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+        INSERT INTO webhook_deliveries (webhook_id, event, payload)
+        VALUES ($1, $2, $3)
+        RETURNING id, status, attempts, next_attempt_at, created_at`
+
+	delivery := WebhookDelivery{WebhookID: webhookID, Event: event, Payload: js}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err = m.DB.QueryRowContext(ctx, query, webhookID, event, js).Scan(
+		&delivery.ID,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// Claim takes up to limit pending deliveries that are due and counts an
+// attempt for each. They are not due again until lease has passed, so that
+// other workers skip them while they are sent and a worker that goes away
+// midway only delays them. The deliveries of inactive webhooks are left
+// pending until the webhook is active again.
+func (m WebhookDeliveryModel) Claim(limit int, lease time.Duration) ([]DueDelivery, error) {
+	query := `
+        UPDATE webhook_deliveries
+        SET attempts = attempts + 1, next_attempt_at = $1
+        FROM webhooks
+        WHERE webhooks.id = webhook_deliveries.webhook_id
+        AND webhook_deliveries.id IN (
+            SELECT webhook_deliveries.id
+            FROM webhook_deliveries
+            INNER JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+            WHERE webhook_deliveries.status = 'pending' AND webhook_deliveries.next_attempt_at <= NOW()
+            AND webhooks.active
+            ORDER BY webhook_deliveries.next_attempt_at ASC
+            LIMIT $2
+            FOR UPDATE OF webhook_deliveries SKIP LOCKED
+        )
+        RETURNING webhook_deliveries.id, webhook_deliveries.webhook_id, webhook_deliveries.event,
+        webhook_deliveries.payload, webhook_deliveries.status, webhook_deliveries.attempts,
+        webhook_deliveries.next_attempt_at, webhook_deliveries.last_status_code, webhook_deliveries.last_error,
+        webhook_deliveries.created_at, webhooks.url, webhooks.secret`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, time.Now().Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	deliveries := []DueDelivery{}
+
+	for rows.Next() {
+		var delivery DueDelivery
+
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.LastStatusCode,
+			&delivery.LastError,
+			&delivery.CreatedAt,
+			&delivery.URL,
+			&delivery.Secret,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// UpdateResult records the outcome of an attempt: the status, the time of
+// the next attempt, the response status code and error, and when the
+// delivery was accepted.
+func (m WebhookDeliveryModel) UpdateResult(delivery *WebhookDelivery) error {
+	query := `
+        UPDATE webhook_deliveries
+        SET status = $1, next_attempt_at = $2, last_status_code = $3, last_error = $4, delivered_at = $5
+        WHERE id = $6`
+
+	args := []any{delivery.Status, delivery.NextAttemptAt, delivery.LastStatusCode, delivery.LastError, delivery.DeliveredAt, delivery.ID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, args...)
+	return err
+}
+
+// GetAllForWebhook lists the deliveries of a webhook, optionally only
+// those with the given status.
+func (m WebhookDeliveryModel) GetAllForWebhook(webhookID int64, status string, filters Filters) ([]WebhookDelivery, Metadata, error) {
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, webhook_id, event, payload, status, attempts, next_attempt_at,
+        last_status_code, last_error, created_at, delivered_at
+        FROM webhook_deliveries
+        WHERE webhook_id = $1
+        AND (status = $2 OR $2 = '')
+        ORDER BY %s %s
+        LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, webhookID, status, filters.limit(), filters.offsett())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	deliveries := []WebhookDelivery{}
+
+	for rows.Next() {
+		var delivery WebhookDelivery
+
+		err := rows.Scan(
+			&totalRecords,
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.LastStatusCode,
+			&delivery.LastError,
+			&delivery.CreatedAt,
+			&delivery.DeliveredAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return deliveries, metadata, nil
+}
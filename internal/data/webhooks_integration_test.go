@@ -0,0 +1,222 @@
+//go:build integration
+// +build integration
+
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomWebhook it's a helper to populate the database
+// with webhooks. It takes in a pointer of `testing.T`, a pointer
+// of `Models`, the owner and the events, and returns a `Webhook`
+// created with fake random data.
+func createRandomWebhook(t *testing.T, m *Models, userID int64, events ...string) Webhook {
+	webhook := Webhook{
+		UserID: userID,
+		URL:    gofakeit.URL(),
+		Secret: gofakeit.Password(true, true, true, false, false, 32),
+		Events: events,
+		Active: true,
+	}
+
+	err := m.Webhooks.Insert(&webhook)
+
+	require.NoError(t, err)
+
+	require.Equal(t, webhook.Version, int32(1))
+	require.NotZero(t, webhook.ID)
+	require.NotZero(t, webhook.CreatedAt)
+
+	return webhook
+}
+
+// webhookModelTestsTeardown it's a helper to truncate the `users`
+// table, and by cascade the webhooks and their deliveries, during tests.
+func webhookModelTestsTeardown(t *testing.T) {
+	t.Helper()
+
+	userModelTestsTeardown(t)
+}
+
+func TestWebhookModelUpdate(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Update a webhook and reject a stale version", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+		webhook := createRandomWebhook(t, &testModels, user.ID, EventMovieCreated)
+
+		stale := webhook
+
+		webhook.Events = []string{EventMovieCreated, EventMovieDeleted}
+		webhook.Active = false
+
+		err := testModels.Webhooks.Update(&webhook)
+		require.NoError(t, err)
+		require.Equal(t, int32(2), webhook.Version)
+
+		got, err := testModels.Webhooks.Get(webhook.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{EventMovieCreated, EventMovieDeleted}, got.Events)
+		require.False(t, got.Active)
+
+		err = testModels.Webhooks.Update(&stale)
+		require.ErrorIs(t, err, ErrEditConflict)
+
+		t.Cleanup(func() {
+			webhookModelTestsTeardown(t)
+		})
+	})
+}
+
+func TestWebhookDeliveryModel(t *testing.T) {
+	testModels := NewModels(testDB)
+
+	t.Run("Enqueue for subscribed webhooks, claim and record the result", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+		subscribed := createRandomWebhook(t, &testModels, user.ID, EventMovieCreated, EventMovieDeleted)
+		createRandomWebhook(t, &testModels, user.ID, EventUserActivated)
+
+		inactive := createRandomWebhook(t, &testModels, user.ID, EventMovieCreated)
+		inactive.Active = false
+		require.NoError(t, testModels.Webhooks.Update(&inactive))
+
+		movie := createRandomMovie(t, &testModels)
+
+		due, err := testModels.WebhookDeliveries.Claim(10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		require.Equal(t, subscribed.ID, due[0].WebhookID)
+		require.Equal(t, subscribed.URL, due[0].URL)
+		require.Equal(t, subscribed.Secret, due[0].Secret)
+		require.Equal(t, 1, due[0].Attempts)
+		require.Equal(t, EventMovieCreated, due[0].Event)
+
+		var payload struct {
+			ID int64 `json:"id"`
+		}
+
+		require.NoError(t, json.Unmarshal(due[0].Payload, &payload))
+		require.Equal(t, movie.ID, payload.ID)
+
+		due, err = testModels.WebhookDeliveries.Claim(10, time.Minute)
+		require.NoError(t, err)
+		require.Empty(t, due, "claimed deliveries are leased")
+
+		delivery := onlyDelivery(t, &testModels, subscribed.ID)
+		delivery.Status = DeliveryPending
+		delivery.NextAttemptAt = time.Now().Add(-time.Second)
+		delivery.LastStatusCode = 500
+		delivery.LastError = "unexpected response status 500"
+
+		require.NoError(t, testModels.WebhookDeliveries.UpdateResult(&delivery))
+
+		due, err = testModels.WebhookDeliveries.Claim(10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		require.Equal(t, 2, due[0].Attempts)
+
+		now := time.Now()
+
+		delivery = due[0].WebhookDelivery
+		delivery.Status = DeliveryDelivered
+		delivery.LastStatusCode = 204
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+
+		require.NoError(t, testModels.WebhookDeliveries.UpdateResult(&delivery))
+
+		filters := Filters{Sort: "-id", SortSafeList: WebhookDeliveriesSortSafeList, Page: 1, PageSize: 20}
+
+		deliveries, metadata, err := testModels.WebhookDeliveries.GetAllForWebhook(subscribed.ID, DeliveryDelivered, filters)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, 1, metadata.TotalRecords)
+		require.Equal(t, 204, deliveries[0].LastStatusCode)
+		require.NotNil(t, deliveries[0].DeliveredAt)
+
+		t.Cleanup(func() {
+			webhookModelTestsTeardown(t)
+			movieModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Enqueue the user activation with the user ID only", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+		webhook := createRandomWebhook(t, &testModels, user.ID, EventUserActivated)
+
+		activated := createRandomUser(t, &testModels)
+
+		err := testModels.Users.Activate(&activated)
+		require.NoError(t, err)
+		require.True(t, activated.Activated)
+
+		delivery := onlyDelivery(t, &testModels, webhook.ID)
+		require.Equal(t, EventUserActivated, delivery.Event)
+		require.JSONEq(t, fmt.Sprintf(`{"id":%d}`, activated.ID), string(delivery.Payload))
+
+		t.Cleanup(func() {
+			webhookModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Leave the deliveries of inactive webhooks pending", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+		webhook := createRandomWebhook(t, &testModels, user.ID, EventMovieCreated)
+
+		_, err := testModels.WebhookDeliveries.EnqueueFor(webhook.ID, EventPing, map[string]int64{"webhookId": webhook.ID})
+		require.NoError(t, err)
+
+		webhook.Active = false
+		require.NoError(t, testModels.Webhooks.Update(&webhook))
+
+		due, err := testModels.WebhookDeliveries.Claim(10, time.Minute)
+		require.NoError(t, err)
+		require.Empty(t, due)
+
+		webhook.Active = true
+		require.NoError(t, testModels.Webhooks.Update(&webhook))
+
+		due, err = testModels.WebhookDeliveries.Claim(10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+
+		t.Cleanup(func() {
+			webhookModelTestsTeardown(t)
+		})
+	})
+
+	t.Run("Enqueue a ping for a single webhook", func(t *testing.T) {
+		user := createRandomUser(t, &testModels)
+		webhook := createRandomWebhook(t, &testModels, user.ID, EventUserActivated)
+
+		delivery, err := testModels.WebhookDeliveries.EnqueueFor(webhook.ID, EventPing, map[string]int64{"webhookId": webhook.ID})
+		require.NoError(t, err)
+		require.NotZero(t, delivery.ID)
+		require.Equal(t, DeliveryPending, delivery.Status)
+		require.Equal(t, 0, delivery.Attempts)
+
+		t.Cleanup(func() {
+			webhookModelTestsTeardown(t)
+		})
+	})
+}
+
+// onlyDelivery returns the only delivery of a webhook.
+func onlyDelivery(t *testing.T, m *Models, webhookID int64) WebhookDelivery {
+	t.Helper()
+
+	filters := Filters{Sort: "id", SortSafeList: WebhookDeliveriesSortSafeList, Page: 1, PageSize: 20}
+
+	deliveries, _, err := m.WebhookDeliveries.GetAllForWebhook(webhookID, "", filters)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+
+	return deliveries[0]
+}
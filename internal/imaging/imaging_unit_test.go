@@ -0,0 +1,47 @@
+//go:build unit
+// +build unit
+
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResize(t *testing.T) {
+	t.Run("Keeps the aspect ratio", func(t *testing.T) {
+		src := image.NewRGBA(image.Rect(0, 0, 400, 600))
+
+		dst := Resize(src, 100)
+
+		require.Equal(t, 100, dst.Bounds().Dx())
+		require.Equal(t, 150, dst.Bounds().Dy())
+	})
+
+	t.Run("Does not upscale narrower images", func(t *testing.T) {
+		src := image.NewRGBA(image.Rect(0, 0, 50, 80))
+
+		dst := Resize(src, 100)
+
+		require.Equal(t, 50, dst.Bounds().Dx())
+		require.Equal(t, 80, dst.Bounds().Dy())
+	})
+
+	t.Run("Averages the covered pixels", func(t *testing.T) {
+		src := image.NewRGBA(image.Rect(10, 10, 12, 11))
+		src.Set(10, 10, color.RGBA{R: 255, A: 255})
+		src.Set(11, 10, color.RGBA{B: 255, A: 255})
+
+		dst := Resize(src, 1)
+
+		r, g, b, a := dst.At(0, 0).RGBA()
+
+		require.InDelta(t, 0x7fff, r, 0x100)
+		require.Zero(t, g)
+		require.InDelta(t, 0x7fff, b, 0x100)
+		require.Equal(t, uint32(0xffff), a)
+	})
+}
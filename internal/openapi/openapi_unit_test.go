@@ -0,0 +1,80 @@
+//go:build unit
+// +build unit
+
+package openapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPath(t *testing.T) {
+	testCases := []struct {
+		route    string
+		expected string
+		params   []string
+	}{
+		{route: "/v1/movies", expected: "/v1/movies"},
+		{route: "/v1/movies/:id", expected: "/v1/movies/{id}", params: []string{"id"}},
+		{route: "/v1/movies/:id/reviews/:reviewId", expected: "/v1/movies/{id}/reviews/{reviewId}", params: []string{"id", "reviewId"}},
+		{route: "/media/*filepath", expected: "/media/{filepath}", params: []string{"filepath"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.route, func(t *testing.T) {
+			path, params := Path(tc.route)
+			require.Equal(t, tc.expected, path)
+			require.Equal(t, tc.params, params)
+		})
+	}
+}
+
+type testDuration int64
+
+type testPoster struct {
+	URL string `json:"url"`
+}
+
+type testMovie struct {
+	ID        int64             `json:"id"`
+	Title     string            `json:"title"`
+	Runtime   testDuration      `json:"runtime,omitempty"`
+	Titles    map[string]string `json:"titles,omitempty"`
+	Poster    *testPoster       `json:"poster,omitempty"`
+	Rating    *float64          `json:"rating"`
+	Hash      []byte            `json:"-"`
+	CreatedAt time.Time         `json:"createdAt"`
+	internal  string
+}
+
+func TestRegistrySchema(t *testing.T) {
+	reg := NewRegistry()
+	reg.Define(testDuration(0), &Schema{Type: "string", Pattern: `^\d+ mins$`})
+
+	s := reg.Schema([]testMovie{})
+	require.Equal(t, "array", s.Type)
+	require.Equal(t, "#/components/schemas/TestMovie", s.Items.Ref)
+
+	movie := reg.Schemas["TestMovie"]
+	require.NotNil(t, movie)
+	require.Equal(t, []string{"id", "title", "createdAt"}, movie.Required)
+	require.Len(t, movie.Properties, 7)
+	require.Equal(t, &Schema{Type: "integer", Format: "int64"}, movie.Properties["id"])
+	require.Equal(t, `^\d+ mins$`, movie.Properties["runtime"].Pattern)
+	require.Equal(t, "string", movie.Properties["titles"].AdditionalProperties.Type)
+	require.Equal(t, "#/components/schemas/TestPoster", movie.Properties["poster"].Ref)
+	require.True(t, movie.Properties["rating"].Nullable)
+	require.Equal(t, "date-time", movie.Properties["createdAt"].Format)
+	require.NotContains(t, movie.Properties, "Hash")
+	require.Contains(t, reg.Schemas, "TestPoster")
+
+	embedded := reg.Schema(struct {
+		testPoster
+		Note string `json:"note,omitempty"`
+	}{})
+	require.Empty(t, embedded.Ref)
+	require.Equal(t, []string{"url"}, embedded.Required)
+	require.Contains(t, embedded.Properties, "note")
+}
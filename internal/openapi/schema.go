@@ -0,0 +1,158 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Registry derives schemas from Go types. Named struct types are defined
+// once as component schemas, named after the type, and referenced
+// everywhere else, other types are described inline.
+type Registry struct {
+	// Schemas holds the component schemas defined so far, by type name.
+	Schemas map[string]*Schema
+
+	defined map[reflect.Type]*Schema
+}
+
+// NewRegistry returns a registry knowing time.Time, which is written as an
+// RFC 3339 string.
+func NewRegistry() *Registry {
+	reg := &Registry{
+		Schemas: make(map[string]*Schema),
+		defined: make(map[reflect.Type]*Schema),
+	}
+
+	reg.Define(time.Time{}, &Schema{Type: "string", Format: "date-time"})
+
+	return reg
+}
+
+// Define sets the schema of the type of v, for types whose JSON form does
+// not follow from their Go type, such as those implementing json.Marshaler.
+func (reg *Registry) Define(v any, s *Schema) {
+	reg.defined[reflect.TypeOf(v)] = s
+}
+
+// Schema returns the schema of the JSON form of v, as written by
+// encoding/json.
+func (reg *Registry) Schema(v any) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+
+	return reg.schemaOf(reflect.TypeOf(v))
+}
+
+// Ref returns the reference to the component schema with the given name.
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (reg *Registry) schemaOf(t reflect.Type) *Schema {
+	if s, ok := reg.defined[t]; ok {
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := reg.schemaOf(t.Elem())
+		if s.Ref != "" {
+			return s
+		}
+
+		nullable := *s
+		nullable.Nullable = true
+
+		return &nullable
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: reg.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: reg.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return reg.structSchema(t)
+		}
+
+		name := componentName(t)
+
+		if _, ok := reg.Schemas[name]; !ok {
+			// Reserve the name first, so recursive types terminate.
+			reg.Schemas[name] = &Schema{}
+			*reg.Schemas[name] = *reg.structSchema(t)
+		}
+
+		return Ref(name)
+	default:
+		return &Schema{}
+	}
+}
+
+// componentName returns the name of the component schema of a named type,
+// its Go name starting with an upper case letter.
+func componentName(t reflect.Type) string {
+	return strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+}
+
+// structSchema describes a struct as an object. Fields are named after
+// their json tags, skipped when tagged "-" or unexported, and required
+// unless they are pointers or tagged omitempty. The fields of embedded
+// structs without a tag are promoted, as encoding/json does.
+func (reg *Registry) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := reg.structSchema(field.Type)
+
+			for key, value := range embedded.Properties {
+				s.Properties[key] = value
+			}
+
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		s.Properties[name] = reg.schemaOf(field.Type)
+
+		if field.Type.Kind() != reflect.Pointer && !strings.Contains(options, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,370 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON values.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrMalformedPatch is wrapped by the errors reporting a patch document
+	// that is not valid JSON or not a valid list of operations.
+	ErrMalformedPatch = errors.New("malformed patch")
+
+	// ErrTestFailed is wrapped by the error reporting a "test" operation
+	// whose value does not match the document.
+	ErrTestFailed = errors.New("test operation failed")
+)
+
+// Operation is a single JSON Patch operation.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Merge applies a JSON Merge Patch to the document. Members of the patch
+// set to null are removed from the document, objects are merged
+// recursively and any other value replaces the one in the document.
+func Merge(doc, patch []byte) ([]byte, error) {
+	var target, changes any
+
+	err := json.Unmarshal(doc, &target)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(patch, &changes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPatch, err)
+	}
+
+	return json.Marshal(merge(target, changes))
+}
+
+func merge(target, changes any) any {
+	patch, ok := changes.(map[string]any)
+	if !ok {
+		return changes
+	}
+
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = make(map[string]any)
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(object, key)
+			continue
+		}
+
+		object[key] = merge(object[key], value)
+	}
+
+	return object
+}
+
+// Apply applies the operations of a JSON Patch to the document, in order.
+// The patch is atomic: the document is returned only if every operation
+// succeeds.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var target any
+
+	err := json.Unmarshal(doc, &target)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []Operation
+
+	err = json.Unmarshal(patch, &ops)
+	if err != nil {
+		return nil, fmt.Errorf("%w: must be an array of operations", ErrMalformedPatch)
+	}
+
+	for i, op := range ops {
+		target, err = apply(target, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func apply(doc any, op Operation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	value := func() (any, error) {
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: %q requires a value", ErrMalformedPatch, op.Op)
+		}
+
+		var v any
+
+		err := json.Unmarshal(op.Value, &v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedPatch, err)
+		}
+
+		return v, nil
+	}
+
+	switch op.Op {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+
+		return add(doc, path, v)
+	case "remove":
+		doc, _, err := remove(doc, path)
+		return doc, err
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+
+		doc, _, err = remove(doc, path)
+		if err != nil {
+			return nil, err
+		}
+
+		return add(doc, path, v)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "move" && len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+			return nil, fmt.Errorf("cannot move %q into one of its children", op.From)
+		}
+
+		var v any
+
+		if op.Op == "move" {
+			doc, v, err = remove(doc, from)
+		} else {
+			v, err = get(doc, from)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return add(doc, path, v)
+	case "test":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !reflect.DeepEqual(current, v) {
+			return nil, fmt.Errorf("%w: %q does not match the given value", ErrTestFailed, op.Path)
+		}
+
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrMalformedPatch, op.Op)
+	}
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: %q is not a valid JSON pointer", ErrMalformedPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	return tokens, nil
+}
+
+func get(doc any, path []string) (any, error) {
+	for i, token := range path {
+		switch container := doc.(type) {
+		case map[string]any:
+			v, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("path %q does not exist", pointer(path[:i+1]))
+			}
+
+			doc = v
+		case []any:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+
+			doc = container[index]
+		default:
+			return nil, fmt.Errorf("path %q does not exist", pointer(path[:i+1]))
+		}
+	}
+
+	return doc, nil
+}
+
+// add inserts the value at the path, replacing an existing object member
+// and shifting array elements. The root is replaced by an empty path.
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	token := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]any:
+		container[token] = value
+		return doc, nil
+	case []any:
+		index := len(container)
+
+		if token != "-" {
+			index, err = arrayIndex(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		container = append(container, nil)
+		copy(container[index+1:], container[index:])
+		container[index] = value
+
+		return set(doc, path[:len(path)-1], container)
+	default:
+		return nil, fmt.Errorf("path %q does not exist", pointer(path))
+	}
+}
+
+// remove deletes the value at the path, returning the updated document and
+// the removed value.
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]any:
+		v, ok := container[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("path %q does not exist", pointer(path))
+		}
+
+		delete(container, token)
+
+		return doc, v, nil
+	case []any:
+		index, err := arrayIndex(token, len(container)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		v := container[index]
+		container = append(container[:index:index], container[index+1:]...)
+
+		doc, err = set(doc, path[:len(path)-1], container)
+
+		return doc, v, err
+	default:
+		return nil, nil, fmt.Errorf("path %q does not exist", pointer(path))
+	}
+}
+
+// set replaces the value at an existing path. It is needed after resizing
+// an array, as the parent still holds the previous slice.
+func set(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	token := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]any:
+		container[token] = value
+	case []any:
+		index, err := arrayIndex(token, len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		container[index] = value
+	}
+
+	return doc, nil
+}
+
+// arrayIndex parses an array index token, which must not be greater than
+// last.
+func arrayIndex(token string, last int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%q is not a valid array index", token)
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("%q is not a valid array index", token)
+	}
+
+	if index > last {
+		return 0, fmt.Errorf("array index %d is out of bounds", index)
+	}
+
+	return index, nil
+}
+
+func pointer(path []string) string {
+	var b strings.Builder
+
+	for _, token := range path {
+		b.WriteString("/")
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+
+	return b.String()
+}
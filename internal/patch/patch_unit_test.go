@@ -0,0 +1,136 @@
+//go:build unit
+// +build unit
+
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	testCases := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+	}{
+		{name: "Replace a member", doc: `{"a":"b"}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{name: "Add a member", doc: `{"a":"b"}`, patch: `{"b":"c"}`, expected: `{"a":"b","b":"c"}`},
+		{name: "Remove a member", doc: `{"a":"b","b":"c"}`, patch: `{"a":null}`, expected: `{"b":"c"}`},
+		{name: "Replace an array", doc: `{"a":["b"]}`, patch: `{"a":["c","d"]}`, expected: `{"a":["c","d"]}`},
+		{name: "Merge nested objects", doc: `{"a":{"b":"c","d":"e"}}`, patch: `{"a":{"d":null,"f":"g"}}`, expected: `{"a":{"b":"c","f":"g"}}`},
+		{name: "Replace the document", doc: `{"a":"b"}`, patch: `["c"]`, expected: `["c"]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				result, err := Merge([]byte(tc.doc), []byte(tc.patch))
+
+				require.NoError(t, err)
+				require.JSONEq(t, tc.expected, string(result))
+			},
+		)
+	}
+
+	t.Run("'ErrMalformedPatch' when the patch is not valid JSON", func(t *testing.T) {
+		_, err := Merge([]byte(`{}`), []byte(`{"a":`))
+
+		require.ErrorIs(t, err, ErrMalformedPatch)
+	})
+}
+
+func TestApply(t *testing.T) {
+	doc := `{"title":"Moana","genres":["animation","adventure"],"a/b":{"~c":1}}`
+
+	testCases := []struct {
+		name     string
+		patch    string
+		expected string
+	}{
+		{
+			name:     "Add a member",
+			patch:    `[{"op":"add","path":"/year","value":2016}]`,
+			expected: `{"title":"Moana","year":2016,"genres":["animation","adventure"],"a/b":{"~c":1}}`,
+		},
+		{
+			name:     "Append to an array",
+			patch:    `[{"op":"add","path":"/genres/-","value":"family"}]`,
+			expected: `{"title":"Moana","genres":["animation","adventure","family"],"a/b":{"~c":1}}`,
+		},
+		{
+			name:     "Insert into an array",
+			patch:    `[{"op":"add","path":"/genres/1","value":"family"}]`,
+			expected: `{"title":"Moana","genres":["animation","family","adventure"],"a/b":{"~c":1}}`,
+		},
+		{
+			name:     "Remove from an array",
+			patch:    `[{"op":"remove","path":"/genres/0"}]`,
+			expected: `{"title":"Moana","genres":["adventure"],"a/b":{"~c":1}}`,
+		},
+		{
+			name:     "Replace a member",
+			patch:    `[{"op":"test","path":"/title","value":"Moana"},{"op":"replace","path":"/title","value":"Vaiana"}]`,
+			expected: `{"title":"Vaiana","genres":["animation","adventure"],"a/b":{"~c":1}}`,
+		},
+		{
+			name:     "Move and copy values",
+			patch:    `[{"op":"copy","from":"/title","path":"/original"},{"op":"move","from":"/genres/1","path":"/genres/0"}]`,
+			expected: `{"title":"Moana","original":"Moana","genres":["adventure","animation"],"a/b":{"~c":1}}`,
+		},
+		{
+			name:     "Escaped pointer tokens",
+			patch:    `[{"op":"replace","path":"/a~1b/~0c","value":2}]`,
+			expected: `{"title":"Moana","genres":["animation","adventure"],"a/b":{"~c":2}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				result, err := Apply([]byte(doc), []byte(tc.patch))
+
+				require.NoError(t, err)
+				require.JSONEq(t, tc.expected, string(result))
+			},
+		)
+	}
+
+	t.Run("'ErrTestFailed' when a test operation does not match", func(t *testing.T) {
+		_, err := Apply([]byte(doc), []byte(`[{"op":"replace","path":"/title","value":"Vaiana"},{"op":"test","path":"/genres/0","value":"comedy"}]`))
+
+		require.ErrorIs(t, err, ErrTestFailed)
+	})
+
+	t.Run("'ErrMalformedPatch' when the patch is not a list of operations", func(t *testing.T) {
+		_, err := Apply([]byte(doc), []byte(`{"op":"remove","path":"/title"}`))
+		require.ErrorIs(t, err, ErrMalformedPatch)
+
+		_, err = Apply([]byte(doc), []byte(`[{"op":"rename","path":"/title"}]`))
+		require.ErrorIs(t, err, ErrMalformedPatch)
+
+		_, err = Apply([]byte(doc), []byte(`[{"op":"add","path":"/year"}]`))
+		require.ErrorIs(t, err, ErrMalformedPatch)
+	})
+
+	t.Run("Error when a path does not exist", func(t *testing.T) {
+		testCases := []string{
+			`[{"op":"remove","path":"/year"}]`,
+			`[{"op":"replace","path":"/genres/2","value":"family"}]`,
+			`[{"op":"add","path":"/genres/3","value":"family"}]`,
+			`[{"op":"add","path":"/credits/0","value":1}]`,
+			`[{"op":"move","from":"/genres","path":"/genres/0"}]`,
+		}
+
+		for _, patch := range testCases {
+			_, err := Apply([]byte(doc), []byte(patch))
+
+			require.Error(t, err, patch)
+			require.NotErrorIs(t, err, ErrMalformedPatch, patch)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CSV writes a list of objects as CSV, with a header row holding every key
+// in the order it first appears. Lists of scalars are joined with "|", as
+// in the movie exports, and other nested values are written as JSON.
+func CSV(w io.Writer, v any) error {
+	rows, ok := v.([]any)
+	if !ok {
+		return ErrNotTabular
+	}
+
+	var columns []string
+	seen := make(map[string]bool)
+
+	for _, row := range rows {
+		object, ok := row.(Object)
+		if !ok {
+			return ErrNotTabular
+		}
+
+		for _, member := range object {
+			if !seen[member.Key] {
+				seen[member.Key] = true
+				columns = append(columns, member.Key)
+			}
+		}
+	}
+
+	if len(columns) == 0 {
+		return nil
+	}
+
+	cw := csv.NewWriter(w)
+
+	err := cw.Write(columns)
+	if err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+
+	for _, row := range rows {
+		object := row.(Object)
+
+		for i, column := range columns {
+			value, _ := object.Get(column)
+
+			record[i], err = csvCell(value)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = cw.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func csvCell(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	case []any:
+		values := make([]string, len(v))
+
+		for i, item := range v {
+			switch item.(type) {
+			case []any, Object:
+				return csvJSON(v)
+			}
+
+			var err error
+
+			values[i], err = csvCell(item)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		return strings.Join(values, "|"), nil
+	default:
+		return csvJSON(v)
+	}
+}
+
+func csvJSON(v any) (string, error) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(js), nil
+}
@@ -0,0 +1,137 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// MessagePack writes the value in the MessagePack format. Numbers are
+// written as integers when they have no fractional part and fit in 64 bits,
+// and as 64-bit floats otherwise.
+func MessagePack(w io.Writer, v any) error {
+	var buf bytes.Buffer
+
+	err := encodeMessagePack(&buf, v)
+	if err != nil {
+		return err
+	}
+
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+func encodeMessagePack(buf *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			writeMessagePackInt(buf, i)
+			return nil
+		}
+
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+
+		buf.WriteByte(0xcb)
+		writeBigEndian(buf, math.Float64bits(f))
+	case string:
+		writeMessagePackHeader(buf, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		buf.WriteString(v)
+	case []any:
+		writeMessagePackHeader(buf, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+
+		for _, item := range v {
+			err := encodeMessagePack(buf, item)
+			if err != nil {
+				return err
+			}
+		}
+	case Object:
+		writeMessagePackHeader(buf, len(v), 0x80, 16, 0, 0xde, 0xdf)
+
+		for _, member := range v {
+			err := encodeMessagePack(buf, member.Key)
+			if err != nil {
+				return err
+			}
+
+			err = encodeMessagePack(buf, member.Value)
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot encode %T as MessagePack", v)
+	}
+
+	return nil
+}
+
+// writeMessagePackHeader writes the type and length of a string, array or
+// map, using the fix format for lengths below fixLimit and the 8, 16 or
+// 32-bit formats otherwise. A zero code8 means there is no 8-bit format.
+func writeMessagePackHeader(buf *bytes.Buffer, n int, fix byte, fixLimit int, code8, code16, code32 byte) {
+	switch {
+	case n < fixLimit:
+		buf.WriteByte(fix | byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		buf.WriteByte(code8)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(code16)
+		writeBigEndian(buf, uint16(n))
+	default:
+		buf.WriteByte(code32)
+		writeBigEndian(buf, uint32(n))
+	}
+}
+
+func writeMessagePackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		buf.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		buf.WriteByte(byte(int8(i)))
+	case i >= 0 && i <= math.MaxUint8:
+		buf.WriteByte(0xcc)
+		buf.WriteByte(byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		writeBigEndian(buf, uint16(i))
+	case i >= 0 && i <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		writeBigEndian(buf, uint32(i))
+	case i >= 0:
+		buf.WriteByte(0xcf)
+		writeBigEndian(buf, uint64(i))
+	case i >= math.MinInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(int8(i)))
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		writeBigEndian(buf, uint16(int16(i)))
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		writeBigEndian(buf, uint32(int32(i)))
+	default:
+		buf.WriteByte(0xd3)
+		writeBigEndian(buf, uint64(i))
+	}
+}
+
+func writeBigEndian[T uint16 | uint32 | uint64](buf *bytes.Buffer, v T) {
+	_ = binary.Write(buf, binary.BigEndian, v)
+}
@@ -0,0 +1,98 @@
+package render
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Negotiate returns the offered media types acceptable according to an
+// Accept header, the preferred ones first. Each offer takes the quality of
+// the most specific media range matching it, offers of equal quality keep
+// their order, and an empty header accepts every offer.
+func Negotiate(accept string, offers []string) []string {
+	if strings.TrimSpace(accept) == "" {
+		return offers
+	}
+
+	type mediaRange struct {
+		typ, subtype string
+		quality      float64
+	}
+
+	var ranges []mediaRange
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+
+		quality := 1.0
+
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, quality: quality})
+	}
+
+	type weighted struct {
+		offer   string
+		quality float64
+	}
+
+	var acceptable []weighted
+
+	for _, offer := range offers {
+		typ, subtype, _ := strings.Cut(offer, "/")
+
+		specificity := -1
+		quality := 0.0
+
+		for _, r := range ranges {
+			var s int
+
+			switch {
+			case r.typ == typ && r.subtype == subtype:
+				s = 2
+			case r.typ == typ && r.subtype == "*":
+				s = 1
+			case r.typ == "*" && r.subtype == "*":
+				s = 0
+			default:
+				continue
+			}
+
+			if s > specificity {
+				specificity = s
+				quality = r.quality
+			}
+		}
+
+		if quality > 0 {
+			acceptable = append(acceptable, weighted{offer: offer, quality: quality})
+		}
+	}
+
+	sort.SliceStable(acceptable, func(i, j int) bool {
+		return acceptable[i].quality > acceptable[j].quality
+	})
+
+	types := make([]string, len(acceptable))
+
+	for i := range acceptable {
+		types[i] = acceptable[i].offer
+	}
+
+	return types
+}
@@ -0,0 +1,137 @@
+// Package render re-encodes JSON documents as XML, MessagePack or CSV.
+//
+// Documents are first decoded into a tree that keeps the order of object
+// members, so every format lists fields in the same order as the JSON
+// representation and shares its formatting of custom types.
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotTabular is returned by CSV for values that are not a list of
+// objects.
+var ErrNotTabular = errors.New("value is not a list of objects")
+
+// Member is a member of a JSON object.
+type Member struct {
+	Key   string
+	Value any
+}
+
+// Object is a JSON object with its members in document order.
+type Object []Member
+
+// Get returns the value of the member with the given key.
+func (o Object) Get(key string) (any, bool) {
+	for _, member := range o {
+		if member.Key == key {
+			return member.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+func (o Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+
+	for i, member := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(member.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(member.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// Decode parses a JSON document into a tree of nil, bool, json.Number,
+// string, []any and Object values.
+func Decode(js []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+
+	v, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("document must only contain a single JSON value")
+	}
+
+	return v, nil
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		object := Object{}
+
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			object = append(object, Member{Key: key.(string), Value: value})
+		}
+
+		_, err = dec.Token()
+
+		return object, err
+	case json.Delim('['):
+		array := []any{}
+
+		for dec.More() {
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			array = append(array, value)
+		}
+
+		_, err = dec.Token()
+
+		return array, err
+	default:
+		if delim, ok := token.(json.Delim); ok {
+			return nil, fmt.Errorf("unexpected delimiter %q", delim)
+		}
+
+		return token, nil
+	}
+}
@@ -0,0 +1,132 @@
+//go:build unit
+// +build unit
+
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDocument = `{"metadata":{"totalRecords":2},"movies":[{"id":1,"title":"Moana, \"Vaiana\"","genres":["animation","family"],"runtime":"107 mins","rating":7.5},{"id":2,"title":"Up","poster":null}]}`
+
+func TestDecode(t *testing.T) {
+	v, err := Decode([]byte(testDocument))
+	require.NoError(t, err)
+
+	env, ok := v.(Object)
+	require.True(t, ok)
+	require.Equal(t, "metadata", env[0].Key)
+	require.Equal(t, "movies", env[1].Key)
+
+	movies, ok := env[1].Value.([]any)
+	require.True(t, ok)
+	require.Len(t, movies, 2)
+
+	title, ok := movies[0].(Object).Get("title")
+	require.True(t, ok)
+	require.Equal(t, `Moana, "Vaiana"`, title)
+
+	js, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, testDocument, string(js))
+
+	_, err = Decode([]byte(`{} {}`))
+	require.Error(t, err)
+}
+
+func TestXML(t *testing.T) {
+	v, err := Decode([]byte(`{"movie":{"id":1,"titles":{"pt-BR":"Up: Altas Aventuras","1st":"x"},"genres":["animation"],"poster":null,"rated":true}}`))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	err = XML(&buf, "response", v)
+	require.NoError(t, err)
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<response><movie><id>1</id><titles><pt-BR>Up: Altas Aventuras</pt-BR><entry key="1st">x</entry></titles>`+
+		`<genres><item>animation</item></genres><poster></poster><rated>true</rated></movie></response>`, buf.String())
+}
+
+func TestMessagePack(t *testing.T) {
+	testCases := []struct {
+		name     string
+		document string
+		expected []byte
+	}{
+		{name: "Null and booleans", document: `[null,true,false]`, expected: []byte{0x93, 0xc0, 0xc3, 0xc2}},
+		{name: "Small integers", document: `[0,127,-1,-32]`, expected: []byte{0x94, 0x00, 0x7f, 0xff, 0xe0}},
+		{name: "Larger integers", document: `[255,2016,-33,70000,-40000]`, expected: []byte{0x95, 0xcc, 0xff, 0xcd, 0x07, 0xe0, 0xd0, 0xdf, 0xce, 0x00, 0x01, 0x11, 0x70, 0xd2, 0xff, 0xff, 0x63, 0xc0}},
+		{name: "Float", document: `1.5`, expected: []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{name: "Map and string", document: `{"id":1,"title":"Up"}`, expected: []byte{0x82, 0xa2, 'i', 'd', 0x01, 0xa5, 't', 'i', 't', 'l', 'e', 0xa2, 'U', 'p'}},
+		{name: "String of 32 bytes", document: `"` + string(bytes.Repeat([]byte{'a'}, 32)) + `"`, expected: append([]byte{0xd9, 32}, bytes.Repeat([]byte{'a'}, 32)...)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				v, err := Decode([]byte(tc.document))
+				require.NoError(t, err)
+
+				var buf bytes.Buffer
+
+				err = MessagePack(&buf, v)
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, buf.Bytes())
+			},
+		)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	v, err := Decode([]byte(testDocument))
+	require.NoError(t, err)
+
+	movies, _ := v.(Object).Get("movies")
+
+	var buf bytes.Buffer
+
+	err = CSV(&buf, movies)
+	require.NoError(t, err)
+	require.Equal(t, "id,title,genres,runtime,rating,poster\n"+
+		"1,\"Moana, \"\"Vaiana\"\"\",animation|family,107 mins,7.5,\n"+
+		"2,Up,,,,\n", buf.String())
+
+	err = CSV(&buf, v)
+	require.ErrorIs(t, err, ErrNotTabular)
+
+	err = CSV(&buf, []any{"Up"})
+	require.ErrorIs(t, err, ErrNotTabular)
+}
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "application/xml", "text/csv"}
+
+	testCases := []struct {
+		name     string
+		accept   string
+		expected []string
+	}{
+		{name: "Empty header", accept: "", expected: offers},
+		{name: "Any media type", accept: "*/*", expected: offers},
+		{name: "Single media type", accept: "text/csv", expected: []string{"text/csv"}},
+		{name: "Ordered by quality", accept: "application/json;q=0.5, text/csv, application/xml;q=0.8", expected: []string{"text/csv", "application/xml", "application/json"}},
+		{name: "Most specific range wins", accept: "text/*;q=0.2, text/csv;q=0, */*", expected: []string{"application/json", "application/xml"}},
+		{name: "Subtype wildcard", accept: "application/*", expected: []string{"application/json", "application/xml"}},
+		{name: "Nothing acceptable", accept: "image/png", expected: []string{}},
+		{name: "Invalid ranges are skipped", accept: "json, text/csv;q=abc, application/xml", expected: []string{"application/xml"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				require.Equal(t, tc.expected, Negotiate(tc.accept, offers))
+			},
+		)
+	}
+}
@@ -0,0 +1,82 @@
+package render
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var xmlNameRX = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+// XML writes the value as an XML document under the given root element.
+// Object members become child elements named after their keys, or "entry"
+// elements with a "key" attribute when the key is not a valid XML name.
+// Array elements become "item" elements.
+func XML(w io.Writer, root string, v any) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+
+	err = encodeXML(enc, xml.StartElement{Name: xml.Name{Local: root}}, v)
+	if err != nil {
+		return err
+	}
+
+	return enc.Flush()
+}
+
+func encodeXML(enc *xml.Encoder, start xml.StartElement, v any) error {
+	err := enc.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case nil:
+	case Object:
+		for _, member := range v {
+			err = encodeXML(enc, xmlElement(member.Key), member.Value)
+			if err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			err = encodeXML(enc, xml.StartElement{Name: xml.Name{Local: "item"}}, item)
+			if err != nil {
+				return err
+			}
+		}
+	case string:
+		err = enc.EncodeToken(xml.CharData(v))
+	case json.Number:
+		err = enc.EncodeToken(xml.CharData(v.String()))
+	case bool:
+		err = enc.EncodeToken(xml.CharData(fmt.Sprint(v)))
+	default:
+		err = fmt.Errorf("cannot encode %T as XML", v)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+func xmlElement(key string) xml.StartElement {
+	if xmlNameRX.MatchString(key) && !strings.HasPrefix(strings.ToLower(key), "xml") {
+		return xml.StartElement{Name: xml.Name{Local: key}}
+	}
+
+	return xml.StartElement{
+		Name: xml.Name{Local: "entry"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: key}},
+	}
+}
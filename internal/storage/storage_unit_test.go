@@ -0,0 +1,73 @@
+//go:build unit
+// +build unit
+
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	t.Run("Successfully put and delete a file", func(t *testing.T) {
+		local, err := NewLocal(t.TempDir(), "http://localhost:4000/media/")
+		require.NoError(t, err)
+
+		err = local.Put(context.Background(), "posters/1/original.jpg", strings.NewReader("poster"), "image/jpeg")
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(filepath.Join(local.Dir(), "posters", "1", "original.jpg"))
+		require.NoError(t, err)
+		require.Equal(t, "poster", string(content))
+
+		require.Equal(t, "http://localhost:4000/media/posters/1/original.jpg", local.URL("posters/1/original.jpg"))
+
+		err = local.Delete(context.Background(), "posters/1/original.jpg")
+		require.NoError(t, err)
+
+		_, err = os.Stat(filepath.Join(local.Dir(), "posters", "1", "original.jpg"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+
+		err = local.Delete(context.Background(), "posters/1/original.jpg")
+		require.NoError(t, err)
+	})
+
+	t.Run("'ErrInvalidKey' when the key escapes the directory", func(t *testing.T) {
+		local, err := NewLocal(t.TempDir(), "/media")
+		require.NoError(t, err)
+
+		for _, key := range []string{"", "/etc/passwd", "../secret", "posters/../../secret", "posters//1"} {
+			err = local.Put(context.Background(), key, strings.NewReader("x"), "text/plain")
+			require.ErrorIs(t, err, ErrInvalidKey, key)
+		}
+	})
+
+	t.Run("Serve files but not directory listings", func(t *testing.T) {
+		local, err := NewLocal(t.TempDir(), "/media")
+		require.NoError(t, err)
+
+		err = local.Put(context.Background(), "posters/1/original.jpg", strings.NewReader("poster"), "image/jpeg")
+		require.NoError(t, err)
+
+		handler := http.FileServer(local.Files())
+
+		for path, status := range map[string]int{
+			"/posters/1/original.jpg": http.StatusOK,
+			"/posters/1/":             http.StatusNotFound,
+			"/posters/1":              http.StatusNotFound,
+			"/":                       http.StatusNotFound,
+		} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+			require.Equal(t, status, rec.Code, path)
+		}
+	})
+}
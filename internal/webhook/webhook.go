@@ -0,0 +1,230 @@
+// Package webhook sends signed webhook requests and verifies their
+// signatures.
+//
+// Every request carries the event in the X-Greenlight-Event header, the
+// delivery ID in X-Greenlight-Delivery, the Unix time it was sent at in
+// X-Greenlight-Timestamp, and in X-Greenlight-Signature the hex-encoded
+// HMAC-SHA256 of the timestamp, a dot and the body, keyed with the secret
+// of the subscription and prefixed with "sha256=".
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	EventHeader     = "X-Greenlight-Event"
+	DeliveryHeader  = "X-Greenlight-Delivery"
+	TimestampHeader = "X-Greenlight-Timestamp"
+	SignatureHeader = "X-Greenlight-Signature"
+)
+
+var (
+	// ErrInvalidSignature is returned by Verify for requests whose
+	// signature does not match their body.
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+
+	// ErrExpiredTimestamp is returned by Verify for requests sent longer
+	// ago than the tolerance, which may be replayed.
+	ErrExpiredTimestamp = errors.New("webhook: timestamp outside of the tolerance")
+
+	// ErrForbiddenAddress is returned by the clients of NewClient for
+	// requests to addresses other than public ones.
+	ErrForbiddenAddress = errors.New("webhook: forbidden address")
+)
+
+// reservedPrefixes are the ranges of public-looking addresses that are not
+// reachable on the Internet, on top of the loopback, private, link-local and
+// multicast ones.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// Sign returns the signature of a body sent at the given time.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+
+	fmt.Fprintf(mac, "%d.", timestamp.Unix())
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a webhook request, as received by a
+// subscriber, rejecting requests sent more than tolerance ago.
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	seconds, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	timestamp := time.Unix(seconds, 0)
+
+	if time.Since(timestamp).Abs() > tolerance {
+		return ErrExpiredTimestamp
+	}
+
+	expected := Sign(secret, timestamp, body)
+
+	if !hmac.Equal([]byte(expected), []byte(header.Get(SignatureHeader))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Backoff returns the delay before retrying a delivery after the given
+// number of failed attempts: 30 seconds after the first, doubling with each
+// attempt up to 12 hours.
+func Backoff(attempts int) time.Duration {
+	const (
+		base    = 30 * time.Second
+		ceiling = 12 * time.Hour
+	)
+
+	delay := base
+
+	for i := 1; i < attempts && delay < ceiling; i++ {
+		delay *= 2
+	}
+
+	if delay > ceiling {
+		return ceiling
+	}
+
+	return delay
+}
+
+// NewClient returns a client for sending deliveries that only connects to
+// public addresses, whatever the URL resolves or redirects to, so that
+// webhooks cannot be used to reach the services of the private network. The
+// connections are checked when made, after name resolution, and no proxy is
+// used. allowPrivate lifts the restriction, for development setups
+// delivering to a local subscriber.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	if !allowPrivate {
+		dialer.Control = checkAddress
+	}
+
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// checkAddress rejects the connections to addresses other than public ones.
+func checkAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+
+	if !Public(addrPort.Addr()) {
+		return ErrForbiddenAddress
+	}
+
+	return nil
+}
+
+// Public reports whether the address is a public unicast one, which
+// deliveries may be sent to.
+func Public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Delivery is a webhook request to send.
+type Delivery struct {
+	ID     int64
+	Event  string
+	URL    string
+	Secret string
+	Body   []byte
+}
+
+// Sender sends webhook deliveries.
+type Sender struct {
+	Client *http.Client
+	// UserAgent is sent in the User-Agent header.
+	UserAgent string
+}
+
+// Send posts the delivery, signed with its secret, and returns the status
+// code of the response. Responses other than 2xx are reported as errors,
+// along with their status code.
+func (s Sender) Send(ctx context.Context, d Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", s.UserAgent)
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(d.Secret, now, d.Body))
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer res.Body.Close()
+
+	// Drain a bounded part of the body so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook: unexpected response status %s", res.Status)
+	}
+
+	return res.StatusCode, nil
+}
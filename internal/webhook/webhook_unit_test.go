@@ -0,0 +1,130 @@
+//go:build unit
+// +build unit
+
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSend(t *testing.T) {
+	const secret = "correct-horse-battery-staple"
+
+	var received http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		received = r.Header.Clone()
+
+		if err := Verify(secret, r.Header, body, time.Minute); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := Sender{Client: server.Client(), UserAgent: "greenlight-test"}
+
+	delivery := Delivery{ID: 42, Event: "movie.created", URL: server.URL, Secret: secret, Body: []byte(`{"event":"movie.created"}`)}
+
+	status, err := sender.Send(context.Background(), delivery)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, status)
+	require.Equal(t, "movie.created", received.Get(EventHeader))
+	require.Equal(t, "42", received.Get(DeliveryHeader))
+	require.Equal(t, "greenlight-test", received.Get("User-Agent"))
+
+	delivery.Secret = "wrong secret"
+
+	status, err = sender.Send(context.Background(), delivery)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"ping"}`)
+	now := time.Now()
+
+	header := make(http.Header)
+	header.Set(TimestampHeader, "1700000000")
+	header.Set(SignatureHeader, Sign("secret", time.Unix(1700000000, 0), body))
+
+	require.ErrorIs(t, Verify("secret", header, body, time.Minute), ErrExpiredTimestamp)
+
+	header.Set(TimestampHeader, "yesterday")
+	require.ErrorIs(t, Verify("secret", header, body, time.Minute), ErrInvalidSignature)
+
+	header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	header.Set(SignatureHeader, Sign("secret", now, body))
+	require.NoError(t, Verify("secret", header, body, time.Minute))
+	require.ErrorIs(t, Verify("secret", header, []byte(`{"event":"movie.deleted"}`), time.Minute), ErrInvalidSignature)
+	require.ErrorIs(t, Verify("other", header, body, time.Minute), ErrInvalidSignature)
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, 30*time.Second, Backoff(1))
+	require.Equal(t, time.Minute, Backoff(2))
+	require.Equal(t, 4*time.Minute, Backoff(4))
+	require.Equal(t, 12*time.Hour, Backoff(20))
+	require.Equal(t, 12*time.Hour, Backoff(1000))
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := Sender{Client: NewClient(time.Second, false)}
+
+	delivery := Delivery{ID: 1, Event: "ping", URL: server.URL, Secret: "secret", Body: []byte(`{"event":"ping"}`)}
+
+	_, err := sender.Send(context.Background(), delivery)
+	require.ErrorIs(t, err, ErrForbiddenAddress)
+
+	sender.Client = NewClient(time.Second, true)
+
+	status, err := sender.Send(context.Background(), delivery)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, status)
+}
+
+func TestPublic(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.1", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.public, Public(netip.MustParseAddr(tt.addr)), tt.addr)
+	}
+}
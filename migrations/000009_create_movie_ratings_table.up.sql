@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS movie_ratings (
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    rating smallint NOT NULL,
+    created_at timestamp (0) with time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp (0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (movie_id, user_id),
+    CONSTRAINT movie_ratings_rating_check CHECK (rating BETWEEN 1 AND 10)
+);
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS rating numeric(4, 2) NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS rating_count integer NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS movies_rating_idx ON movies (rating);
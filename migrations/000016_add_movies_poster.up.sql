@@ -0,0 +1 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS poster jsonb;
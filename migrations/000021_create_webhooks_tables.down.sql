@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE code = 'webhooks:write';
+
+DROP TABLE IF EXISTS webhook_deliveries;
+
+DROP TABLE IF EXISTS webhooks;
@@ -0,0 +1,5 @@
+UPDATE movie_imports SET status = 'completed' WHERE status = 'failed';
+
+ALTER TABLE movie_imports DROP CONSTRAINT IF EXISTS movie_imports_status_check;
+
+ALTER TABLE movie_imports ADD CONSTRAINT movie_imports_status_check CHECK (status IN ('pending', 'running', 'completed'));
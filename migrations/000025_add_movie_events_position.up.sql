@@ -0,0 +1,34 @@
+CREATE SEQUENCE IF NOT EXISTS movie_events_position_seq;
+
+ALTER TABLE movie_events ADD COLUMN IF NOT EXISTS position bigint;
+
+UPDATE movie_events SET position = id;
+
+SELECT setval('movie_events_position_seq', COALESCE((SELECT MAX(id) FROM movie_events), 0) + 1, false);
+
+CREATE UNIQUE INDEX IF NOT EXISTS movie_events_position_idx ON movie_events (position);
+
+-- The position is assigned when the transaction logging the event commits,
+-- under a lock held until the commit completes, so that positions follow the
+-- commit order: an event is never visible before one with a lower position.
+CREATE OR REPLACE FUNCTION movie_events_assign_position() RETURNS trigger AS $$
+DECLARE
+    assigned bigint;
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('movie_events_position'));
+
+    UPDATE movie_events
+    SET position = nextval('movie_events_position_seq')
+    WHERE id = NEW.id
+    RETURNING position INTO assigned;
+
+    PERFORM pg_notify('movie_events', assigned::text);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER movie_events_assign_position
+AFTER INSERT ON movie_events
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW EXECUTE FUNCTION movie_events_assign_position();